@@ -1,6 +1,8 @@
 package authorization
 
 import (
+	"reflect"
+
 	"github.com/pkg/errors"
 
 	"github.com/lalloni/fabrikit/chaincode/context"
@@ -13,3 +15,17 @@ func Allowed(*context.Context) error {
 func Forbidden(*context.Context) error {
 	return errors.New("not allowed")
 }
+
+// Describe devuelve la autorización en palabras que requieren Allowed y Forbidden
+func Describe(c Check) string {
+	if c == nil {
+		return ""
+	}
+	switch reflect.ValueOf(c).Pointer() {
+	case reflect.ValueOf(Allowed).Pointer():
+		return "anyone"
+	case reflect.ValueOf(Forbidden).Pointer():
+		return "no one"
+	}
+	return ""
+}
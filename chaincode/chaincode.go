@@ -3,6 +3,7 @@ package chaincode
 import (
 	"bytes"
 	"encoding/json"
+	"time"
 	"unicode/utf8"
 
 	"github.com/hyperledger/fabric/core/chaincode/shim"
@@ -15,7 +16,10 @@ import (
 	"github.com/lalloni/fabrikit/chaincode/router"
 )
 
-func New(name string, version string, r router.Router) shim.Chaincode {
+// EventName es el nombre del evento de chaincode emitido por cada transacción
+var EventName = "fabrikit"
+
+func New(name string, version string, r router.Router, opts ...Option) shim.Chaincode {
 	log := logging.ChaincodeLogger(name)
 	log.Info("created")
 	res := &cc{
@@ -24,43 +28,84 @@ func New(name string, version string, r router.Router) shim.Chaincode {
 		router:  r,
 		log:     log,
 	}
+	for _, opt := range opts {
+		opt(res)
+	}
 	return res
 }
 
 type cc struct {
-	name    string
-	version string
-	router  router.Router
-	log     *shim.ChaincodeLogger
+	name       string
+	version    string
+	router     router.Router
+	log        *shim.ChaincodeLogger
+	panicstack bool
+}
+
+type Option func(*cc)
+
+// WithPanicStack incluye el stack de los panics en el fault con la opción debug
+func WithPanicStack(b bool) Option {
+	return func(c *cc) {
+		c.panicstack = b
+	}
 }
 
-func (c *cc) Init(stub shim.ChaincodeStubInterface) peer.Response {
-	ctx := context.New(stub, c.name, c.version, "init")
+func (c *cc) Init(stub shim.ChaincodeStubInterface) (res peer.Response) {
+	var ctx *context.Context
+	defer c.protect(stub, &ctx, &res)
+	ctx = context.New(stub, c.name, c.version, "init")
 	logger := ctx.Logger()
 	logger.Debug("begin request processing")
 	handle := c.router.InitHandler()
-	if handle != nil {
-		return c.response(ctx, logger, handle(ctx))
+	if handle == nil {
+		res = c.response(ctx, logger, response.OK(nil), 0)
+		logger.Debugf("end request processing with response status %q", res.GetStatus())
+		return res
 	}
-	res := c.response(ctx, logger, response.OK(nil))
-	logger.Debugf("end request processing with response status %q", res.GetStatus())
+	r, elapsed := c.handle(ctx, logger, handle)
+	res = c.response(ctx, logger, r, elapsed)
+	logger.Debugf("end request processing with response status %q in %s", res.GetStatus(), elapsed)
 	return res
 }
 
-func (c *cc) Invoke(stub shim.ChaincodeStubInterface) peer.Response {
-	ctx := context.New(stub, c.name, c.version, "invoke", stub.GetTxID())
+func (c *cc) Invoke(stub shim.ChaincodeStubInterface) (res peer.Response) {
+	var ctx *context.Context
+	defer c.protect(stub, &ctx, &res)
+	ctx = context.New(stub, c.name, c.version, "invoke", stub.GetTxID())
 	logger := ctx.Logger(ctx.Function())
 	logger.Debug("begin request processing")
 	handle := c.router.Handler(router.Name(ctx.Function()))
 	if handle == nil {
 		handle = handler.NotImplementedHandler
 	}
-	res := c.response(ctx, logger, handle(ctx))
-	logger.Debugf("end request processing with response status %q", res.GetStatus())
+	r, elapsed := c.handle(ctx, logger, handle)
+	res = c.response(ctx, logger, r, elapsed)
+	logger.Debugf("end request processing with response status %q in %s", res.GetStatus(), elapsed)
 	return res
 }
 
-func (c *cc) response(ctx *context.Context, logger *shim.ChaincodeLogger, r *response.Response) peer.Response {
+func (c *cc) handle(ctx *context.Context, logger *shim.ChaincodeLogger, handle handler.Handler) (*response.Response, time.Duration) {
+	start := time.Now()
+	r := handle(ctx)
+	elapsed := time.Since(start)
+	if !r.OK() {
+		return r, elapsed
+	}
+	if ev := ctx.Event(); ev != nil {
+		bs, err := json.Marshal(ev)
+		if err != nil {
+			return response.Error("encoding chaincode event: %v", err), elapsed
+		}
+		logger.Debugf("setting chaincode event %q with %d changes and %d entries", EventName, len(ev.Changes), len(ev.Entries))
+		if err := ctx.Stub.SetEvent(EventName, bs); err != nil {
+			return response.Error("setting chaincode event: %v", err), elapsed
+		}
+	}
+	return r, elapsed
+}
+
+func (c *cc) response(ctx *context.Context, logger *shim.ChaincodeLogger, r *response.Response, elapsed time.Duration) peer.Response {
 	if r.Status < 0 {
 		return r.Payload.Content.(peer.Response)
 	}
@@ -87,6 +132,18 @@ func (c *cc) response(ctx *context.Context, logger *shim.ChaincodeLogger, r *res
 		if mspid != "" || subject != "" || issuer != "" {
 			r.Payload.Client = &response.Client{MSPID: mspid, Subject: subject, Issuer: issuer}
 		}
+		m := ctx.Store.Metrics()
+		r.Payload.Metrics = &response.Metrics{
+			HandlerTime:        elapsed.String(),
+			StatesRead:         m.StatesRead,
+			RangeScans:         m.RangeScans,
+			KeysWritten:        m.KeysWritten,
+			KeysDeleted:        m.KeysDeleted,
+			PlainBytesWritten:  m.PlainBytesWritten,
+			StoredBytesWritten: m.StoredBytesWritten,
+			StoredBytesRead:    m.StoredBytesRead,
+			PlainBytesRead:     m.PlainBytesRead,
+		}
 	}
 	if r.Payload != nil {
 		if bs, ok := r.Payload.Content.([]byte); ok {
@@ -102,7 +159,7 @@ func (c *cc) response(ctx *context.Context, logger *shim.ChaincodeLogger, r *res
 		enc.SetEscapeHTML(false) // do not html-escape "<", ">", "&"
 		err := enc.Encode(r.Payload)
 		if err != nil {
-			return c.response(ctx, logger, response.Error("encoding response payload: %v", err))
+			return c.response(ctx, logger, response.Error("encoding response payload: %v", err), elapsed)
 		}
 		payload = b.Bytes()
 		// drop extra newline added by enc.Encode()
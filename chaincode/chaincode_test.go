@@ -1,15 +1,27 @@
 package chaincode_test
 
 import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
 	"testing"
 
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/hyperledger/fabric/protos/peer"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 
+	"github.com/lalloni/fabrikit/chaincode"
 	"github.com/lalloni/fabrikit/chaincode/authorization"
 	"github.com/lalloni/fabrikit/chaincode/context"
+	"github.com/lalloni/fabrikit/chaincode/handler/param"
+	"github.com/lalloni/fabrikit/chaincode/handlerutil/crud"
 	"github.com/lalloni/fabrikit/chaincode/response"
 	"github.com/lalloni/fabrikit/chaincode/response/status"
 	"github.com/lalloni/fabrikit/chaincode/router"
+	"github.com/lalloni/fabrikit/chaincode/store"
+	"github.com/lalloni/fabrikit/chaincode/store/key"
 	"github.com/lalloni/fabrikit/chaincode/test"
 )
 
@@ -85,6 +97,8 @@ func TestDebugCall(t *testing.T) {
 	tx, res, p, err := test.MockInvoke(t, mock, "success?debug")
 	a.NoError(err)
 	a.EqualValues(status.OK, res.Status)
+	a.NotNil(p.Metrics)
+	p.Metrics = nil
 	a.EqualValues(&response.Payload{
 		Content:     "blah!",
 		Chaincode:   &response.Chaincode{Version: "test"},
@@ -95,6 +109,8 @@ func TestDebugCall(t *testing.T) {
 	tx, res, p, err = test.MockInvoke(t, mock, "fail?debug")
 	a.NoError(err)
 	a.EqualValues(status.Error, res.Status)
+	a.NotNil(p.Metrics)
+	p.Metrics = nil
 	a.EqualValues(&response.Payload{
 		Chaincode:   &response.Chaincode{Version: "test"},
 		Transaction: &response.Transaction{ID: tx, Function: "fail"},
@@ -102,3 +118,379 @@ func TestDebugCall(t *testing.T) {
 	a.EqualValues("bleh!", res.Message)
 
 }
+
+func TestDebugMetrics(t *testing.T) {
+	a := assert.New(t)
+
+	r := router.New()
+	r.SetHandler("put", nil, func(ctx *context.Context) *response.Response {
+		if err := ctx.Store.PutComposite(persons, &person{ID: 1, Name: "pepe"}); err != nil {
+			return response.Error(err.Error())
+		}
+		if _, err := ctx.Store.GetCompositeAll(persons); err != nil {
+			return response.Error(err.Error())
+		}
+		return response.OK(nil)
+	})
+	mock := test.NewMock("cc", r)
+
+	_, res, p, err := test.MockInvoke(t, mock, "put")
+	a.NoError(err)
+	a.EqualValues(status.OK, res.Status)
+	a.Nil(p.Metrics)
+
+	_, res, p, err = test.MockInvoke(t, mock, "put?debug")
+	a.NoError(err)
+	a.EqualValues(status.OK, res.Status)
+	m := p.Metrics
+	a.NotEmpty(m.HandlerTime)
+	a.Equal(1, m.KeysWritten) // root only as the witness already exists
+	a.Equal(1, m.RangeScans)
+	a.Equal(3, m.StatesRead) // witness check & range scan of witness & root
+	a.Equal(m.PlainBytesWritten, m.StoredBytesWritten)
+	a.True(m.StoredBytesWritten > 0)
+}
+
+type person struct {
+	ID   uint64 `json:"id,omitempty"`
+	Name string `json:"name,omitempty"`
+}
+
+var persons = store.MustPrepare(store.Composite{
+	Name:            "person",
+	Creator:         func() interface{} { return &person{} },
+	KeyBaseName:     "person",
+	IdentifierField: "ID",
+	IdentifierKey: func(id interface{}) (*key.Key, error) {
+		return key.NewBase("person", strconv.FormatUint(id.(uint64), 10)), nil
+	},
+	KeyIdentifier: func(k *key.Key) (interface{}, error) {
+		return strconv.ParseUint(k.Base[0].Value, 10, 64)
+	},
+	Events:      true,
+	EventValues: true,
+})
+
+func TestEvents(t *testing.T) {
+	a := assert.New(t)
+
+	r := router.New()
+	r.SetHandler("put", nil, func(ctx *context.Context) *response.Response {
+		if err := ctx.Store.PutComposite(persons, &person{ID: 1, Name: "pepe"}); err != nil {
+			return response.Error(err.Error())
+		}
+		if err := ctx.Store.DelComposite(persons, uint64(2)); err != nil {
+			return response.Error(err.Error())
+		}
+		ctx.Emit("custom", "blah")
+		return response.OK(nil)
+	})
+	r.SetHandler("fail", nil, func(ctx *context.Context) *response.Response {
+		ctx.Emit("custom", "blah")
+		return response.Error("bleh!")
+	})
+	mock := test.NewMock("cc", r)
+
+	_, res, _, err := test.MockInvoke(t, mock, "put")
+	a.NoError(err)
+	a.EqualValues(status.OK, res.Status)
+	a.Len(mock.ChaincodeEventsChannel, 1)
+	ev := <-mock.ChaincodeEventsChannel
+	a.EqualValues(chaincode.EventName, ev.EventName)
+	a.JSONEq(`{
+		"changes":[
+			{"schema":"person","id":1,"operation":"put","value":{"id":1,"name":"pepe"}},
+			{"schema":"person","id":2,"operation":"delete"}
+		],
+		"entries":[{"name":"custom","payload":"blah"}]
+	}`, string(ev.Payload))
+
+	_, res, _, err = test.MockInvoke(t, mock, "fail")
+	a.NoError(err)
+	a.EqualValues(status.Error, res.Status)
+	a.Len(mock.ChaincodeEventsChannel, 0)
+}
+
+func TestInvokeChaincode(t *testing.T) {
+	a := assert.New(t)
+
+	type fault struct {
+		Reason string `json:"reason"`
+	}
+
+	other := router.New()
+	other.SetHandler("echo", nil, func(ctx *context.Context) *response.Response {
+		p := &person{}
+		if err := json.Unmarshal(ctx.Stub.GetArgs()[2], p); err != nil {
+			return response.BadRequest(err.Error())
+		}
+		_, debug := ctx.Option("debug")
+		p.Name = fmt.Sprintf("%s %s %v", p.Name, ctx.Stub.GetArgs()[1], debug)
+		return response.OK(p)
+	})
+	other.SetHandler("fail", nil, func(ctx *context.Context) *response.Response {
+		return response.StatusWithFault(status.NotFound, &fault{Reason: "missing"})
+	})
+	other.SetHandler("plain", nil, func(ctx *context.Context) *response.Response {
+		return response.Direct(peer.Response{Status: status.NotFound, Message: "gone", Payload: []byte("not json")})
+	})
+	otherMock := test.NewMock("other", other)
+
+	var (
+		res        *person
+		ferr, perr error
+		f, pf      *fault
+	)
+	r := router.New()
+	r.SetHandler("call", nil, func(ctx *context.Context) *response.Response {
+		res = &person{}
+		if err := ctx.Invoke(&context.Invocation{
+			Chaincode: "other",
+			Function:  "echo",
+			Options:   map[string]string{"debug": ""},
+			Args:      []interface{}{"hi", &person{ID: 1, Name: "pepe"}},
+		}, res, nil); err != nil {
+			return response.Error(err.Error())
+		}
+		f = &fault{}
+		ferr = ctx.Invoke(&context.Invocation{Chaincode: "other", Function: "fail"}, nil, f)
+		pf = &fault{}
+		perr = ctx.Invoke(&context.Invocation{Chaincode: "other", Function: "plain"}, nil, pf)
+		return response.OK(nil)
+	})
+	mock := test.NewMock("cc", r)
+	mock.MockPeerChaincode("other", otherMock)
+
+	_, rs, _, err := test.MockInvoke(t, mock, "call")
+	a.NoError(err)
+	a.EqualValues(status.OK, rs.Status, rs.Message)
+	a.Equal(&person{ID: 1, Name: "pepe hi true"}, res)
+	a.IsType(&context.NotFoundError{}, ferr)
+	a.EqualValues(status.NotFound, ferr.(*context.NotFoundError).Status)
+	a.Equal(&fault{Reason: "missing"}, f)
+	a.IsType(&context.NotFoundError{}, perr)
+	a.EqualValues("gone", perr.(*context.NotFoundError).Message)
+	a.Equal(&fault{}, pf)
+}
+
+func TestRemoteStore(t *testing.T) {
+	a := assert.New(t)
+
+	owner := router.New()
+	crud.AddHandlers(owner, persons, crud.WithDefaults())
+	ownerMock := test.NewMock("owner", owner)
+	tx := test.MockTransactionStart(t, ownerMock)
+	for id := 1; id <= 3; id++ {
+		a.NoError(store.New(ownerMock).PutComposite(persons, &person{ID: uint64(id), Name: "p" + strconv.Itoa(id)}))
+	}
+	test.MockTransactionEnd(t, ownerMock, tx)
+
+	var (
+		got, missing interface{}
+		has          bool
+		all, rng     []interface{}
+		perr, nerr   error
+	)
+	r := router.New()
+	r.SetHandler("read", nil, func(ctx *context.Context) *response.Response {
+		st := store.Remote(ctx.Stub, "owner", "")
+		var err error
+		if got, err = st.GetComposite(persons, uint64(2)); err != nil {
+			return response.Error(err.Error())
+		}
+		if missing, err = st.GetComposite(persons, uint64(9)); err != nil {
+			return response.Error(err.Error())
+		}
+		if has, err = st.HasComposite(persons, uint64(3)); err != nil {
+			return response.Error(err.Error())
+		}
+		if all, err = st.GetCompositeAll(persons); err != nil {
+			return response.Error(err.Error())
+		}
+		if rng, err = st.GetCompositeRange(persons, store.R(uint64(2), uint64(3))); err != nil {
+			return response.Error(err.Error())
+		}
+		perr = st.PutComposite(persons, &person{ID: 4})
+		_, nerr = store.Remote(ctx.Stub, "none", "").GetComposite(persons, uint64(1))
+		return response.OK(nil)
+	})
+	mock := test.NewMock("cc", r)
+	mock.MockPeerChaincode("owner", ownerMock)
+	mock.MockPeerChaincode("none", test.NewMock("none", router.New()))
+
+	_, res, _, err := test.MockInvoke(t, mock, "read")
+	a.NoError(err)
+	a.EqualValues(status.OK, res.Status, res.Message)
+	a.Equal(&person{ID: 2, Name: "p2"}, got)
+	a.Nil(missing)
+	a.True(has)
+	a.Len(all, 3)
+	a.Equal([]interface{}{&person{ID: 2, Name: "p2"}, &person{ID: 3, Name: "p3"}}, rng)
+	a.Equal(store.ErrReadOnly, errors.Cause(perr))
+	a.IsType(&context.BadRequestError{}, errors.Cause(nerr))
+}
+
+func TestCrudNamedArgs(t *testing.T) {
+	a := assert.New(t)
+
+	r := router.New()
+	crud.AddHandlers(r, persons, crud.WithDefaults(), crud.WithIDParam(param.Uint64),
+		crud.WithItemParam(param.JSON(reflect.TypeOf(&person{}))))
+	mock := test.NewMock("cc", r)
+
+	_, res, _, err := test.MockInvoke(t, mock, "PutPerson?named", `{"item":{"id":1,"name":"pepe"}}`)
+	a.NoError(err)
+	a.EqualValues(status.OK, res.Status, res.Message)
+
+	_, res, p, err := test.MockInvoke(t, mock, "GetPersonRange?named", `{"from":1,"to":2}`)
+	a.NoError(err)
+	a.EqualValues(status.OK, res.Status, res.Message)
+	a.Len(p.Content, 1)
+
+	_, res, _, err = test.MockInvoke(t, mock, "GetPerson?named", `{"key":1}`)
+	a.NoError(err)
+	a.EqualValues(status.BadRequest, res.Status)
+	a.Contains(res.Message, `unknown arguments "key"`)
+}
+
+func TestCrudIntegrityLimit(t *testing.T) {
+	a := assert.New(t)
+
+	r := router.New()
+	crud.AddHandlers(r, persons, crud.WithIntegrity(true), crud.WithIntegrityCheck(authorization.Allowed))
+	mock := test.NewMock("cc", r)
+
+	_, res, _, err := test.MockInvoke(t, mock, "CheckPersonIntegrity", "", strconv.Itoa(store.MaxIntegrityLimit+1), "none")
+	a.NoError(err)
+	a.EqualValues(status.BadRequest, res.Status)
+	a.Contains(res.Message, "invalid integrity check limit")
+
+	_, res, _, err = test.MockInvoke(t, mock, "CheckPersonIntegrity", "", strconv.Itoa(store.MaxIntegrityLimit), "none")
+	a.NoError(err)
+	a.EqualValues(status.OK, res.Status, res.Message)
+}
+
+func TestCrudExportSize(t *testing.T) {
+	a := assert.New(t)
+
+	r := router.New()
+	crud.AddHandlers(r, persons, crud.WithExport(true), crud.WithReadCheck(authorization.Allowed))
+	mock := test.NewMock("cc", r)
+
+	_, res, _, err := test.MockInvoke(t, mock, "ExportPerson", "", strconv.Itoa(store.MaxPageSize+1))
+	a.NoError(err)
+	a.EqualValues(status.BadRequest, res.Status)
+	a.Contains(res.Message, "invalid person export size")
+
+	_, res, _, err = test.MockInvoke(t, mock, "ExportPerson", "", strconv.Itoa(store.MaxPageSize))
+	a.NoError(err)
+	a.EqualValues(status.OK, res.Status, res.Message)
+}
+
+var archived = store.MustPrepare(store.Composite{
+	Name:            "archived",
+	Creator:         func() interface{} { return &person{} },
+	KeyBaseName:     "archived",
+	IdentifierField: "ID",
+	IdentifierKey: func(id interface{}) (*key.Key, error) {
+		return key.NewBase("archived", strconv.FormatUint(id.(uint64), 10)), nil
+	},
+	KeyIdentifier: func(k *key.Key) (interface{}, error) {
+		return strconv.ParseUint(k.Base[0].Value, 10, 64)
+	},
+	Validations: []store.Rule{
+		store.Length("Name", 0, 10),
+	},
+	SoftDelete: true,
+})
+
+func TestCrudDeleted(t *testing.T) {
+	a := assert.New(t)
+
+	r := router.New()
+	crud.AddHandlers(r, archived, crud.WithDefaults(), crud.WithIDParam(param.Uint64),
+		crud.WithItemParam(param.JSON(reflect.TypeOf(&person{}))))
+	mock := test.NewMock("cc", r)
+
+	_, res, _, err := test.MockInvoke(t, mock, "PutArchived", `{"id":1,"name":"pepe"}`)
+	a.NoError(err)
+	a.EqualValues(status.OK, res.Status, res.Message)
+	_, res, _, err = test.MockInvoke(t, mock, "DelArchived", "1")
+	a.NoError(err)
+	a.EqualValues(status.OK, res.Status, res.Message)
+
+	_, res, _, err = test.MockInvoke(t, mock, "GetArchived", "1")
+	a.NoError(err)
+	a.EqualValues(status.NotFound, res.Status)
+
+	_, res, p, err := test.MockInvoke(t, mock, "GetArchived?deleted", "1")
+	a.NoError(err)
+	a.EqualValues(status.OK, res.Status, res.Message)
+	a.Equal(map[string]interface{}{"id": 1.0, "name": "pepe"}, p.Content)
+
+	_, res, p, err = test.MockInvoke(t, mock, "GetArchivedRange?deleted", "1", "2")
+	a.NoError(err)
+	a.EqualValues(status.OK, res.Status, res.Message)
+	a.Len(p.Content, 1)
+
+	_, res, p, err = test.MockInvoke(t, mock, "HasArchived", "1")
+	a.NoError(err)
+	a.EqualValues(status.OK, res.Status, res.Message)
+	a.Equal(false, p.Content)
+}
+
+func TestCrudValidation(t *testing.T) {
+	a := assert.New(t)
+
+	r := router.New()
+	crud.AddHandlers(r, archived, crud.WithDefaults(), crud.WithIDParam(param.Uint64),
+		crud.WithItemParam(param.JSON(reflect.TypeOf(&person{}))))
+	mock := test.NewMock("cc", r)
+
+	_, res, p, err := test.MockInvoke(t, mock, "PutArchived", `{"id":1,"name":"a very long name"}`)
+	a.NoError(err)
+	a.EqualValues(status.BadRequest, res.Status)
+	a.Contains(res.Message, `putting archived: invalid composite "archived"`)
+	a.NotNil(p.Fault)
+}
+
+func TestPanicRecovery(t *testing.T) {
+	a := assert.New(t)
+
+	r := router.New()
+	r.SetHandler("boom", nil, func(*context.Context) *response.Response {
+		var m map[string]int
+		m["x"] = 1
+		return response.OK(nil)
+	})
+	mock := shim.NewMockStub("cc", chaincode.New("cc", "test", r, chaincode.WithPanicStack(true)))
+
+	_, res, p, err := test.MockInvoke(t, mock, "boom")
+	a.NoError(err)
+	a.EqualValues(status.Error, res.Status)
+	fault := p.Fault.(map[string]interface{})
+	a.Len(fault["fault-id"], 16)
+	a.Nil(fault["stack"])
+	a.Contains(res.Message, fault["fault-id"])
+
+	_, res, p, err = test.MockInvoke(t, mock, "boom?debug")
+	a.NoError(err)
+	a.EqualValues(status.Error, res.Status)
+	a.Contains(p.Fault.(map[string]interface{})["stack"], "TestPanicRecovery")
+
+	// el panic ocurre al crear el contexto
+	stub := &panicStub{MockStub: shim.NewMockStub("cc", nil)}
+	stub.MockTransactionStart("tx")
+	pr := chaincode.New("cc", "test", r).Invoke(stub)
+	a.EqualValues(shim.ERROR, pr.Status)
+	a.Contains(pr.Message, "internal error (fault id ")
+	a.Nil(pr.Payload)
+}
+
+type panicStub struct {
+	*shim.MockStub
+}
+
+func (*panicStub) GetArgs() [][]byte {
+	panic("no args")
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/lalloni/fabrikit/chaincode/logging"
+	"github.com/lalloni/fabrikit/chaincode/response"
 	"github.com/lalloni/fabrikit/chaincode/store"
 )
 
@@ -18,8 +19,12 @@ func New(stub shim.ChaincodeStubInterface, name, version string, path ...string)
 		version: version,
 		path:    append([]string{name, version}, path...),
 		Stub:    stub,
-		Store:   store.New(stub),
 	}
+	opts, err := store.Options(stub, "audit")
+	if err != nil {
+		c.Logger().Warningf("parsing store options: %v", err)
+	}
+	c.Store = store.New(stub, opts...)
 	args := stub.GetArgs()
 	if len(args) > 0 {
 		fun, opts, err := ParseFunction(args[0])
@@ -43,6 +48,13 @@ type Context struct {
 	clientid    cid.ClientIdentity
 	clientcrt   *x509.Certificate
 	clientmspid string
+	events      []EventEntry
+	namedargs   bool
+	classifiers []func(error) *response.Response
+}
+
+func (ctx *Context) Name() string {
+	return ctx.name
 }
 
 func (ctx *Context) Version() string {
@@ -103,6 +115,26 @@ func (ctx *Context) Option(name string) (string, bool) {
 	return v, present
 }
 
+// NamedArgs indica si los argumentos se reciben como un único objeto JSON
+func (ctx *Context) NamedArgs() bool {
+	_, named := ctx.options["named"]
+	return named || ctx.namedargs
+}
+
+func (ctx *Context) SetNamedArgs(b bool) {
+	ctx.namedargs = b
+}
+
+// ErrorClassifiers devuelve los clasificadores de errores de la función invocada
+func (ctx *Context) ErrorClassifiers() []func(error) *response.Response {
+	return ctx.classifiers
+}
+
+// AddErrorClassifiers agrega clasificadores con precedencia sobre los existentes
+func (ctx *Context) AddErrorClassifiers(cs ...func(error) *response.Response) {
+	ctx.classifiers = append(append([]func(error) *response.Response(nil), cs...), ctx.classifiers...)
+}
+
 func (ctx *Context) ArgBytes(n int) ([]byte, error) {
 	args := ctx.Stub.GetArgs()
 	if len(args) < n+1 {
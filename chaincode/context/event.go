@@ -0,0 +1,30 @@
+package context
+
+import (
+	"github.com/lalloni/fabrikit/chaincode/store"
+)
+
+// Event es el único evento de chaincode de la transacción
+type Event struct {
+	Changes []store.Change `json:"changes,omitempty"`
+	Entries []EventEntry   `json:"entries,omitempty"`
+}
+
+type EventEntry struct {
+	Name    string      `json:"name,omitempty"`
+	Payload interface{} `json:"payload,omitempty"`
+}
+
+// Emit agrega una entrada al evento de la transacción
+func (ctx *Context) Emit(name string, payload interface{}) {
+	ctx.events = append(ctx.events, EventEntry{Name: name, Payload: payload})
+}
+
+// Event devuelve el evento de la transacción o nil si no hay nada que emitir
+func (ctx *Context) Event() *Event {
+	changes := ctx.Store.Changes()
+	if len(changes) == 0 && len(ctx.events) == 0 {
+		return nil
+	}
+	return &Event{Changes: changes, Entries: ctx.events}
+}
@@ -0,0 +1,21 @@
+package context
+
+import (
+	"github.com/lalloni/fabrikit/chaincode/internal/invocation"
+)
+
+// Invocation describe la invocación de una función de otro chaincode
+type Invocation = invocation.Invocation
+
+// InvocationError es el error de una función invocada que responde con un status no OK
+type InvocationError = invocation.InvocationError
+
+type BadRequestError = invocation.BadRequestError
+type ForbiddenError = invocation.ForbiddenError
+type NotFoundError = invocation.NotFoundError
+type ConflictError = invocation.ConflictError
+
+// Invoke invoca la función descripta por inv y decodifica su respuesta en content
+func (ctx *Context) Invoke(inv *Invocation, content interface{}, fault interface{}) error {
+	return invocation.Invoke(ctx.Stub, ctx.Logger(), inv, content, fault)
+}
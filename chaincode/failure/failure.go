@@ -0,0 +1,65 @@
+// Package failure define errores de dominio con el estado de su respuesta
+package failure
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+
+	"github.com/lalloni/fabrikit/chaincode/response/status"
+)
+
+type Error struct {
+	Status  int32
+	Message string
+	// Fault es el detalle estructurado del error incluido en la respuesta
+	Fault interface{}
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// WithFault agrega al error el detalle estructurado incluido en la respuesta
+func (e *Error) WithFault(fault interface{}) *Error {
+	e.Fault = fault
+	return e
+}
+
+func New(status int32, format string, args ...interface{}) *Error {
+	return &Error{Status: status, Message: fmt.Sprintf(format, args...)}
+}
+
+func BadRequest(format string, args ...interface{}) *Error {
+	return New(status.BadRequest, format, args...)
+}
+
+func Forbidden(format string, args ...interface{}) *Error {
+	return New(status.Forbidden, format, args...)
+}
+
+func NotFound(format string, args ...interface{}) *Error {
+	return New(status.NotFound, format, args...)
+}
+
+func Conflict(format string, args ...interface{}) *Error {
+	return New(status.Conflict, format, args...)
+}
+
+func Internal(format string, args ...interface{}) *Error {
+	return New(status.Error, format, args...)
+}
+
+// As devuelve el *Error que causó err (ver errors.Cause), si lo hay
+func As(err error) (*Error, bool) {
+	e, ok := errors.Cause(err).(*Error)
+	return e, ok
+}
+
+// StatusOf devuelve el estado de err o status.Error si no lo tiene
+func StatusOf(err error) int32 {
+	if e, ok := As(err); ok {
+		return e.Status
+	}
+	return status.Error
+}
@@ -1,6 +1,8 @@
 package handler
 
 import (
+	"fmt"
+	"reflect"
 	"strings"
 
 	"github.com/pkg/errors"
@@ -17,23 +19,121 @@ func CheckArgsCount(ctx *context.Context, expected int) error {
 	return nil
 }
 
+// ExtractArgs extrae los argumentos posicionales (ver Extract)
 func ExtractArgs(args [][]byte, pars ...param.Param) ([]interface{}, error) {
-	parc := len(pars)
+	if err := positionalOnly(pars); err != nil {
+		return nil, err
+	}
+	min, max, err := Cardinality(pars...)
+	if err != nil {
+		return nil, err
+	}
 	argc := len(args)
-	if parc != argc {
-		return nil, errors.Errorf("argument count mismatch: received %d while expecting %d%s", argc, parc, names(pars))
+	if argc < min || (max >= 0 && argc > max) {
+		return nil, errors.Errorf("argument count mismatch: received %d while expecting %s%s", argc, expecting(min, max), names(pars))
 	}
 	res := []interface{}(nil)
+	aerr := &ArgumentsError{}
 	for i, par := range pars {
+		if param.IsVariadic(par) {
+			vs := variadic(par, argc-i)
+			for j := i; j < argc; j++ {
+				v, err := par.From(args[j])
+				if err != nil {
+					aerr.add(j+1, par.Name(), err)
+					continue
+				}
+				vs = reflect.Append(vs, value(v, vs.Type().Elem()))
+			}
+			res = append(res, vs.Interface())
+			break
+		}
+		if i >= argc {
+			def, _ := param.IsOptional(par)
+			res = append(res, def)
+			continue
+		}
 		v, err := par.From(args[i])
 		if err != nil {
-			return nil, errors.Wrapf(err, "%s argument %d", par.Name(), i+1)
+			aerr.add(i+1, par.Name(), err)
+			continue
 		}
 		res = append(res, v)
 	}
+	if err := aerr.err(); err != nil {
+		return nil, err
+	}
 	return res, nil
 }
 
+// Cardinality devuelve la mínima y máxima (-1 sin límite) cantidad de argumentos
+func Cardinality(pars ...param.Param) (min, max int, err error) {
+	optional := false
+	positional := 0
+	for i, par := range pars {
+		src, _ := param.SourceOf(par)
+		if src != param.Positional {
+			if param.IsVariadic(par) {
+				return 0, 0, errors.Errorf("%s parameter %d (%s) can not be variadic", src, i+1, par.Name())
+			}
+			continue
+		}
+		positional++
+		switch _, opt := param.IsOptional(par); {
+		case param.IsVariadic(par):
+			if i != len(pars)-1 {
+				return 0, 0, errors.Errorf("variadic parameter %d (%s) must be the last one", i+1, par.Name())
+			}
+			return min, -1, nil
+		case opt:
+			optional = true
+		case optional:
+			return 0, 0, errors.Errorf("required parameter %d (%s) must not follow optional parameters", i+1, par.Name())
+		default:
+			min++
+		}
+	}
+	return min, positional, nil
+}
+
+func positionalOnly(pars []param.Param) error {
+	for i, par := range pars {
+		if src, _ := param.SourceOf(par); src != param.Positional {
+			return errors.Errorf("%s parameter %d (%s) must be extracted with Extract", src, i+1, par.Name())
+		}
+	}
+	return nil
+}
+
+func expecting(min, max int) string {
+	switch {
+	case max < 0:
+		return fmt.Sprintf("at least %d", min)
+	case min == max:
+		return fmt.Sprint(min)
+	default:
+		return fmt.Sprintf("%d to %d", min, max)
+	}
+}
+
+func variadic(par param.Param, n int) reflect.Value {
+	t := reflect.TypeOf([]interface{}(nil))
+	if tp, ok := par.(param.TypedParam); ok {
+		t = param.VariadicType(tp)
+	}
+	if n < 0 {
+		n = 0
+	}
+	return reflect.MakeSlice(t, 0, n)
+}
+
+func value(v interface{}, t reflect.Type) reflect.Value {
+	if v == nil {
+		return reflect.Zero(t)
+	}
+	return reflect.ValueOf(v)
+}
+
 func names(pars []param.Param) string {
 	ss := []string(nil)
 	for _, par := range pars {
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -27,6 +28,16 @@ func TestExtract(t *testing.T) {
 		{"1 extra arg", args{bytes("1"), pars()}, wants(), true},
 		{"2 extra args", args{bytes("1", "2"), pars()}, wants(), true},
 		{"1 extra args", args{bytes("1", "2"), pars(param.Uint64)}, wants(), true},
+		{"optional given", args{bytes("1", "a"), pars(param.Uint64, param.Optional(param.String, "x"))}, wants(uint64(1), "a"), false},
+		{"optional omitted", args{bytes("1"), pars(param.Uint64, param.Optional(param.String, "x"))}, wants(uint64(1), "x"), false},
+		{"optional zero", args{bytes(), pars(param.Optional(param.Uint64, nil))}, wants(uint64(0)), false},
+		{"optional extra", args{bytes("1", "2"), pars(param.Optional(param.Uint64, nil))}, wants(), true},
+		{"required after optional", args{bytes("1", "2"), pars(param.Optional(param.Uint64, nil), param.Uint64)}, wants(), true},
+		{"variadic empty", args{bytes("a"), pars(param.String, param.Variadic(param.Uint64))}, wants("a", []uint64{}), false},
+		{"variadic", args{bytes("a", "1", "2"), pars(param.String, param.Variadic(param.Uint64))}, wants("a", []uint64{1, 2}), false},
+		{"variadic missing", args{bytes(), pars(param.String, param.Variadic(param.Uint64))}, wants(), true},
+		{"variadic invalid", args{bytes("a", "1", "x"), pars(param.String, param.Variadic(param.Uint64))}, wants(), true},
+		{"variadic not last", args{bytes("1"), pars(param.Variadic(param.Uint64), param.String)}, wants(), true},
 	}
 	for _, tt := range tests {
 		tt := tt
@@ -43,6 +54,47 @@ func TestExtract(t *testing.T) {
 	}
 }
 
+func TestExtractNamed(t *testing.T) {
+	id := param.Named("id", param.Uint64)
+	name := param.Named("name", param.String)
+	tests := []struct {
+		name    string
+		args    [][]byte
+		pars    []param.Param
+		want    []interface{}
+		wantErr string
+	}{
+		{"no args no pars", bytes(), pars(), wants(), ""},
+		{"empty object", bytes("{}"), pars(), wants(), ""},
+		{"not an object", bytes("[1]"), pars(id), wants(), "must be a JSON object"},
+		{"two args", bytes("{}", "{}"), pars(), wants(), "argument count mismatch"},
+		{"ok", bytes(`{"name":"bla","id":1}`), pars(id, name), wants(uint64(1), "bla"), ""},
+		{"missing", bytes(`{"id":1,"name":null}`), pars(id, name), wants(), `missing arguments "name"`},
+		{"unknown", bytes(`{"id":1,"x":2,"name":"a","b":3}`), pars(id, name), wants(), `unknown arguments "b", "x"`},
+		{"invalid", bytes(`{"id":"x"}`), pars(id), wants(), `argument "id": invalid natural integer`},
+		{"optional", bytes(`{"id":1}`), pars(id, param.Optional(name, "z")), wants(uint64(1), "z"), ""},
+		{"variadic", bytes(`{"ids":[1,"2"]}`), pars(param.Variadic(param.Named("ids", param.Uint64))), wants([]uint64{1, 2}), ""},
+		{"variadic omitted", bytes(`{}`), pars(param.Variadic(param.Named("ids", param.Uint64))), wants([]uint64{}), ""},
+		{"variadic not array", bytes(`{"ids":1}`), pars(param.Variadic(param.Named("ids", param.Uint64))), wants(), "must be a JSON array"},
+		{"json string", bytes(`{"s":"bla"}`), pars(param.Named("s", param.JSON(reflect.TypeOf("")))), wants("bla"), ""},
+		{"json list", bytes(`{"l":["a","b"]}`), pars(param.Named("l", param.List(param.JSON(reflect.TypeOf(""))))), wants([]string{"a", "b"}), ""},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			a := assert.New(t)
+			got, err := ExtractNamedArgs(tt.args, tt.pars...)
+			if tt.wantErr != "" {
+				a.Error(err)
+				a.Contains(err.Error(), tt.wantErr)
+			} else {
+				a.NoError(err)
+			}
+			a.EqualValues(tt.want, got)
+		})
+	}
+}
+
 func wants(vv ...interface{}) []interface{} {
 	return vv
 }
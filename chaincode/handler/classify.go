@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/lalloni/fabrikit/chaincode/context"
+	"github.com/lalloni/fabrikit/chaincode/failure"
+	"github.com/lalloni/fabrikit/chaincode/response"
+	"github.com/lalloni/fabrikit/chaincode/response/status"
+	"github.com/lalloni/fabrikit/chaincode/store"
+)
+
+// Classifier convierte un error en una respuesta o devuelve nil si no lo reconoce
+type Classifier func(error) *response.Response
+
+// Classify agrega los clasificadores con los que se convierten los errores de Func
+func Classify(cs ...Classifier) func(Handler) Handler {
+	fs := []func(error) *response.Response(nil)
+	for _, c := range cs {
+		fs = append(fs, c)
+	}
+	return func(h Handler) Handler {
+		return func(ctx *context.Context) *response.Response {
+			ctx.AddErrorClassifiers(fs...)
+			return h(ctx)
+		}
+	}
+}
+
+// ErrorResponse convierte err en una respuesta usando los clasificadores del contexto
+func ErrorResponse(ctx *context.Context, err error) *response.Response {
+	for _, c := range ctx.ErrorClassifiers() {
+		if res := c(err); res != nil {
+			return res
+		}
+	}
+	return DefaultClassifier(err)
+}
+
+// DefaultClassifier es el clasificador que se usa cuando ningún otro reconoce el error
+func DefaultClassifier(err error) *response.Response {
+	var res *response.Response
+	switch e := errors.Cause(err).(type) {
+	case *failure.Error:
+		res = response.StatusWithMessage(e.Status, "%s", err.Error())
+		if e.Fault != nil {
+			res.Payload = &response.Payload{Fault: e.Fault}
+		}
+	case *store.ValidationError:
+		res = response.BadRequest("%s", err.Error())
+		res.Payload = &response.Payload{Fault: e}
+	case *ArgumentsError:
+		res = ArgsResponse(err)
+	default:
+		res = response.StatusWithMessage(status.Error, "%s", err.Error())
+	}
+	return res
+}
@@ -12,7 +12,10 @@ import (
 	"github.com/lalloni/fabrikit/chaincode/response"
 )
 
-var contextType = reflect.TypeOf(&context.Context{})
+var (
+	contextType = reflect.TypeOf(&context.Context{})
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+)
 
 func MustFunc(function interface{}, pars ...param.TypedParam) Handler {
 	h, err := Func(function, pars...)
@@ -22,6 +25,7 @@ func MustFunc(function interface{}, pars ...param.TypedParam) Handler {
 	return h
 }
 
+// Func crea un handler que extrae los argumentos con pars y llama a function
 func Func(function interface{}, pars ...param.TypedParam) (Handler, error) {
 
 	fun := reflect.ValueOf(function)
@@ -33,8 +37,11 @@ func Func(function interface{}, pars ...param.TypedParam) (Handler, error) {
 
 	funName := runtime.FuncForPC(fun.Pointer()).Name()
 
-	if funType.NumOut() != 1 {
-		return nil, errors.Errorf("function %s must return 1 value", funName)
+	switch {
+	case funType.NumOut() == 1:
+	case funType.NumOut() == 2 && funType.Out(1) == errorType:
+	default:
+		return nil, errors.Errorf("function %s must return 1 value or a value and an error", funName)
 	}
 
 	cardinality := len(pars)
@@ -51,34 +58,69 @@ func Func(function interface{}, pars ...param.TypedParam) (Handler, error) {
 		return nil, errors.Errorf("function %s parameter 0 must be a %s", funName, contextType)
 	}
 
+	if _, _, err := Cardinality(param.Untyped(pars...)...); err != nil {
+		return nil, errors.Wrapf(err, "function %s", funName)
+	}
+
+	variadic := cardinality > 0 && param.IsVariadic(pars[cardinality-1])
+
+	if variadic != funType.IsVariadic() {
+		if variadic {
+			return nil, errors.Errorf("function %s must be variadic to bind variadic parameter %d", funName, cardinality)
+		}
+		return nil, errors.Errorf("function %s is variadic but parameter %d is not", funName, cardinality)
+	}
+
 	for i, par := range pars {
 		t := funType.In(i + 1)
+		if variadic && i == cardinality-1 {
+			t = t.Elem()
+		}
 		if !t.AssignableTo(par.Type()) {
 			return nil, errors.Errorf("function %s parameter %d must be %s (assignable to %s type) but is %s", funName, i+1, par.Name(), par.Type(), t)
 		}
 	}
 
 	return func(ctx *context.Context) *response.Response {
-		args, err := ExtractArgs(ctx.Stub.GetArgs()[1:], param.Untyped(pars...)...)
+		args, err := Extract(ctx, param.Untyped(pars...)...)
 		if err != nil {
-			return response.BadRequest(err.Error())
+			return ArgsResponse(err)
 		}
 		vals := []reflect.Value{reflect.ValueOf(ctx)}
-		for _, arg := range args {
-			vals = append(vals, reflect.ValueOf(arg))
+		for i, arg := range args {
+			vals = append(vals, value(arg, funType.In(i+1)))
+		}
+		var outs []reflect.Value
+		if variadic {
+			outs = fun.CallSlice(vals)
+		} else {
+			outs = fun.Call(vals)
 		}
-		ret := fun.Call(vals)[0].Interface()
-		if res, ok := ret.(*response.Response); ok {
-			return res
+		if len(outs) == 2 && !outs[1].IsNil() {
+			return ErrorResponse(ctx, outs[1].Interface().(error))
 		}
-		return response.OK(ret)
+		return result(ctx, outs[0].Interface())
 	}, nil
 
 }
 
+func result(ctx *context.Context, ret interface{}) *response.Response {
+	switch v := ret.(type) {
+	case *response.Response:
+		return v
+	case error:
+		return ErrorResponse(ctx, v)
+	}
+	return response.OK(ret)
+}
+
 func types(pars []param.TypedParam) string {
 	ss := []string{contextType.String()}
 	for _, par := range pars {
+		if param.IsVariadic(par) {
+			ss = append(ss, "..."+par.Type().String())
+			continue
+		}
 		ss = append(ss, par.Type().String())
 	}
 	return strings.Join(ss, ", ")
@@ -23,12 +23,20 @@ func TestFunc(t *testing.T) {
 		{"not a function", args{"bla", nil}, true},
 		{"bad return 1", args{func() (string, int, int) { return "", 0, 0 }, nil}, true},
 		{"bad return 2", args{func() {}, nil}, true},
+		{"bad return 3", args{func(*context.Context) (int, int) { return 0, 0 }, nil}, true},
+		{"return value and error ok", args{func(*context.Context) (int, error) { return 0, nil }, nil}, false},
+		{"return error ok", args{func(*context.Context) error { return nil }, nil}, false},
 		{"return ok", args{func(*context.Context) int { return 0 }, nil}, false},
 		{"no ctx", args{func(int) int { return 0 }, nil}, true},
 		{"bad args from params 1", args{func(*context.Context, int, string) int { return 0 }, nil}, true},
 		{"1 param ok", args{func(*context.Context, int) int { return 0 }, []param.TypedParam{param.Typed("integer", reflect.TypeOf(0), nil)}}, false},
 		{"bad params 1", args{func(*context.Context, string) int { return 0 }, []param.TypedParam{param.Typed("integer", reflect.TypeOf(0), nil)}}, true},
 		{"bad params 2", args{func(*context.Context, string, int) int { return 0 }, []param.TypedParam{param.Typed("integer", reflect.TypeOf(0), nil)}}, true},
+		{"variadic ok", args{func(*context.Context, ...uint64) int { return 0 }, []param.TypedParam{param.Variadic(param.Uint64)}}, false},
+		{"variadic not bound", args{func(*context.Context, []uint64) int { return 0 }, []param.TypedParam{param.Variadic(param.Uint64)}}, true},
+		{"variadic function", args{func(*context.Context, ...uint64) int { return 0 }, []param.TypedParam{param.Uint64}}, true},
+		{"variadic without params", args{func(*context.Context, ...uint64) int { return 0 }, nil}, true},
+		{"required after optional", args{func(*context.Context, uint64, string) int { return 0 }, []param.TypedParam{param.Optional(param.Uint64, nil), param.String}}, true},
 		{"2 params ok", args{func(*context.Context, string, int) int { return 0 }, []param.TypedParam{param.Typed("string", reflect.TypeOf(""), nil), param.Typed("integer", reflect.TypeOf(0), nil)}}, false},
 	}
 	for _, tt := range tests {
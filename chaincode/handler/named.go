@@ -0,0 +1,221 @@
+package handler
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/lalloni/fabrikit/chaincode/context"
+	"github.com/lalloni/fabrikit/chaincode/handler/param"
+	"github.com/lalloni/fabrikit/chaincode/response"
+)
+
+// NamedArgs hace que el handler reciba sus argumentos nombrados
+func NamedArgs(h Handler) Handler {
+	return func(ctx *context.Context) *response.Response {
+		ctx.SetNamedArgs(true)
+		return h(ctx)
+	}
+}
+
+// Extract extrae los argumentos de la llamada sean nombrados o posicionales
+func Extract(ctx *context.Context, pars ...param.Param) ([]interface{}, error) {
+	positional := []param.Param(nil)
+	sourced := map[int]interface{}{}
+	serr := &ArgumentsError{}
+	for i, par := range pars {
+		src, key := param.SourceOf(par)
+		if src == param.Positional {
+			positional = append(positional, par)
+			continue
+		}
+		v, err := sourcedArg(ctx, par, src, key)
+		if err != nil {
+			serr.addSourced(src, key, par.Name(), err)
+			continue
+		}
+		sourced[i] = v
+	}
+	args := ctx.Stub.GetArgs()[1:]
+	var vals []interface{}
+	var err error
+	if ctx.NamedArgs() {
+		vals, err = ExtractNamedArgs(args, positional...)
+	} else {
+		vals, err = ExtractArgs(args, positional...)
+	}
+	if err != nil {
+		if aerr, ok := err.(*ArgumentsError); ok {
+			aerr.Problems = append(aerr.Problems, serr.Problems...)
+		}
+		return nil, err
+	}
+	if err := serr.err(); err != nil {
+		return nil, err
+	}
+	res := []interface{}(nil)
+	for i := range pars {
+		if v, ok := sourced[i]; ok {
+			res = append(res, v)
+			continue
+		}
+		res = append(res, vals[0])
+		vals = vals[1:]
+	}
+	return res, nil
+}
+
+func sourcedArg(ctx *context.Context, par param.Param, src param.Source, key string) (interface{}, error) {
+	var arg []byte
+	var found bool
+	switch src {
+	case param.Transient:
+		tm, err := ctx.Stub.GetTransient()
+		if err != nil {
+			return nil, errors.Wrap(err, "getting transient map")
+		}
+		arg, found = tm[key]
+	case param.FunctionOption:
+		var s string
+		s, found = ctx.Option(key)
+		arg = []byte(s)
+	}
+	if !found {
+		if def, ok := param.IsOptional(par); ok {
+			return def, nil
+		}
+		return nil, errors.New("is required")
+	}
+	return par.From(arg)
+}
+
+// ExtractNamedArgs extrae los argumentos de un único objeto JSON
+func ExtractNamedArgs(args [][]byte, pars ...param.Param) ([]interface{}, error) {
+	if err := positionalOnly(pars); err != nil {
+		return nil, err
+	}
+	if _, _, err := Cardinality(pars...); err != nil {
+		return nil, err
+	}
+	if len(args) == 0 {
+		args = [][]byte{[]byte("{}")} // sin argumentos equivale a un objeto vacío
+	}
+	if len(args) != 1 {
+		return nil, errors.Errorf("argument count mismatch: received %d while expecting a single JSON object with named arguments%s", len(args), names(pars))
+	}
+	obj := map[string]json.RawMessage{}
+	if err := json.Unmarshal(args[0], &obj); err != nil || obj == nil {
+		return nil, errors.Errorf("named arguments must be a JSON object%s", names(pars))
+	}
+	known := map[string]bool{}
+	missing := []string(nil)
+	for _, par := range pars {
+		known[par.Name()] = true
+		_, optional := param.IsOptional(par)
+		if raw, ok := obj[par.Name()]; (!ok || isNull(raw)) && !optional && !param.IsVariadic(par) {
+			missing = append(missing, par.Name())
+		}
+	}
+	unknown := []string(nil)
+	for k := range obj {
+		if !known[k] {
+			unknown = append(unknown, k)
+		}
+	}
+	sort.Strings(unknown)
+	problems := []string(nil)
+	if len(missing) > 0 {
+		problems = append(problems, "missing arguments "+quoted(missing))
+	}
+	if len(unknown) > 0 {
+		problems = append(problems, "unknown arguments "+quoted(unknown))
+	}
+	if len(problems) > 0 {
+		return nil, errors.Errorf("named arguments mismatch: %s", strings.Join(problems, " and "))
+	}
+	res := []interface{}(nil)
+	aerr := &ArgumentsError{}
+	for _, par := range pars {
+		raw, ok := obj[par.Name()]
+		if !ok || isNull(raw) {
+			if param.IsVariadic(par) {
+				res = append(res, variadic(par, 0).Interface())
+			} else {
+				def, _ := param.IsOptional(par)
+				res = append(res, def)
+			}
+			continue
+		}
+		if param.IsVariadic(par) {
+			v, err := namedVariadic(par, raw)
+			if err != nil {
+				aerr.add(0, par.Name(), err)
+				continue
+			}
+			res = append(res, v)
+			continue
+		}
+		arg, err := namedArg(par, raw)
+		if err == nil {
+			var v interface{}
+			if v, err = par.From(arg); err == nil {
+				res = append(res, v)
+				continue
+			}
+		}
+		aerr.add(0, par.Name(), err)
+	}
+	if err := aerr.err(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func namedVariadic(par param.Param, raw json.RawMessage) (interface{}, error) {
+	raws := []json.RawMessage(nil)
+	if err := json.Unmarshal(raw, &raws); err != nil {
+		return nil, errors.New("variadic argument must be a JSON array")
+	}
+	vs := variadic(par, len(raws))
+	for i, raw := range raws {
+		arg, err := namedArg(par, raw)
+		if err != nil {
+			return nil, errors.Wrapf(err, "item %d", i+1)
+		}
+		v, err := par.From(arg)
+		if err != nil {
+			return nil, errors.Wrapf(err, "item %d", i+1)
+		}
+		vs = reflect.Append(vs, value(v, vs.Type().Elem()))
+	}
+	return vs.Interface(), nil
+}
+
+func namedArg(par param.Param, raw json.RawMessage) ([]byte, error) {
+	if param.IsJSON(par) {
+		return raw, nil
+	}
+	if strings.HasPrefix(strings.TrimSpace(string(raw)), `"`) {
+		s := ""
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return nil, err
+		}
+		return []byte(s), nil
+	}
+	return raw, nil
+}
+
+func isNull(raw json.RawMessage) bool {
+	return strings.TrimSpace(string(raw)) == "null"
+}
+
+func quoted(ss []string) string {
+	qs := []string(nil)
+	for _, s := range ss {
+		qs = append(qs, `"`+s+`"`)
+	}
+	return strings.Join(qs, ", ")
+}
@@ -0,0 +1,177 @@
+package param
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+// ConstraintError es el error de un valor que no cumple una restricción de su param
+type ConstraintError struct {
+	Param   string `json:"param,omitempty"`
+	Rule    string `json:"rule,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+func (e *ConstraintError) Error() string {
+	return fmt.Sprintf("%s %s (rule %s)", e.Param, e.Message, e.Rule)
+}
+
+// Constrain restringe los valores de p a los que cumplen con check
+func Constrain[P Param](p P, rule string, check func(v interface{}) string) P {
+	name := p.Name()
+	next := func(v interface{}) (interface{}, error) {
+		if msg := check(v); msg != "" {
+			return nil, &ConstraintError{Param: name, Rule: rule, Message: msg}
+		}
+		return v, nil
+	}
+	var r interface{}
+	switch q := Param(p).(type) {
+	case specializer:
+		r = q.specialize(SpecializeTyped(q.typed(), name, next))
+	case TypedParam:
+		r = SpecializeTyped(q, name, next)
+	default:
+		r = Specialize(q, name, next)
+	}
+	res := r.(P)
+	if t := traitsOf(p); !t.plain() {
+		res = reshape(res, func(rt *traits) { *rt = t })
+	}
+	return res
+}
+
+type specializer interface {
+	typed() TypedParam
+	specialize(TypedParam) interface{}
+}
+
+func (o Of[T]) typed() TypedParam {
+	return o.TypedParam
+}
+
+func (o Of[T]) specialize(p TypedParam) interface{} {
+	return Of[T]{TypedParam: p}
+}
+
+// Min restringe los valores numéricos a los mayores o iguales a min
+func Min[P Param](p P, min float64) P {
+	return Constrain(p, "min", func(v interface{}) string {
+		n, ok := number(v)
+		if !ok {
+			return fmt.Sprintf("is not a number (%T)", v)
+		}
+		if n < min {
+			return fmt.Sprintf("value %v is less than %v", n, min)
+		}
+		return ""
+	})
+}
+
+// Max restringe los valores numéricos a los menores o iguales a max
+func Max[P Param](p P, max float64) P {
+	return Constrain(p, "max", func(v interface{}) string {
+		n, ok := number(v)
+		if !ok {
+			return fmt.Sprintf("is not a number (%T)", v)
+		}
+		if n > max {
+			return fmt.Sprintf("value %v is greater than %v", n, max)
+		}
+		return ""
+	})
+}
+
+// Length restringe la longitud de strings, slices y maps (max negativo es sin límite)
+func Length[P Param](p P, min, max int) P {
+	return Constrain(p, "length", func(v interface{}) string {
+		var l int
+		rv := reflect.ValueOf(v)
+		switch rv.Kind() {
+		case reflect.String:
+			l = utf8.RuneCountInString(rv.String())
+		case reflect.Slice, reflect.Map, reflect.Array:
+			l = rv.Len()
+		default:
+			return fmt.Sprintf("has no length (%T)", v)
+		}
+		if l < min {
+			return fmt.Sprintf("length %d is less than %d", l, min)
+		}
+		if max >= 0 && l > max {
+			return fmt.Sprintf("length %d is greater than %d", l, max)
+		}
+		return ""
+	})
+}
+
+func Pattern[P Param](p P, expr string) P {
+	re := regexp.MustCompile(expr)
+	return Constrain(p, "pattern", func(v interface{}) string {
+		s, ok := v.(string)
+		if !ok {
+			return fmt.Sprintf("is not a string (%T)", v)
+		}
+		if !re.MatchString(s) {
+			return fmt.Sprintf("value %q does not match pattern %q", s, expr)
+		}
+		return ""
+	})
+}
+
+// OneOf restringe los valores a alguno de values
+func OneOf[P Param](p P, values ...interface{}) P {
+	ss := []string(nil)
+	for _, value := range values {
+		ss = append(ss, fmt.Sprint(value))
+	}
+	return Constrain(p, "one-of", func(v interface{}) string {
+		for _, value := range values {
+			if reflect.DeepEqual(v, value) {
+				return ""
+			}
+		}
+		return fmt.Sprintf("value %v is not one of %s", v, strings.Join(ss, ", "))
+	})
+}
+
+// Custom restringe los valores a los que f acepta
+func Custom[P Param](p P, rule string, f func(v interface{}) error) P {
+	return Constrain(p, rule, func(v interface{}) string {
+		if err := f(v); err != nil {
+			return err.Error()
+		}
+		return ""
+	})
+}
+
+// AsConstraintError devuelve la violación de restricción que causó err, si la hay
+func AsConstraintError(err error) (*ConstraintError, bool) {
+	ce, ok := errors.Cause(err).(*ConstraintError)
+	return ce, ok
+}
+
+func number(v interface{}) (float64, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+	if d, ok := v.(Decimal); ok {
+		f := float64(d.Units)
+		for i := 0; i < d.Scale; i++ {
+			f /= 10
+		}
+		return f, true
+	}
+	return 0, false
+}
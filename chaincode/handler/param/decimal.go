@@ -0,0 +1,112 @@
+package param
+
+import (
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Decimal es un número de punto fijo cuyo valor es Units / 10^Scale
+type Decimal struct {
+	Units int64
+	Scale int
+}
+
+// ParseDecimal interpreta s como un decimal con a lo sumo scale decimales
+func ParseDecimal(s string, scale int) (Decimal, error) {
+	d := Decimal{Scale: scale}
+	digits := strings.TrimLeft(s, "+-")
+	ip, fp := digits, ""
+	if i := strings.IndexByte(digits, '.'); i >= 0 {
+		ip, fp = digits[:i], digits[i+1:]
+	}
+	if (ip == "" && fp == "") || len(digits) < len(s)-1 || !numeric(ip) || !numeric(fp) {
+		return d, errors.Errorf("invalid decimal: '%v'", s)
+	}
+	if len(fp) > scale {
+		return d, errors.Errorf("invalid decimal: more than %d fractional digits: '%v'", scale, s)
+	}
+	n, ok := new(big.Int).SetString("0"+ip+fp+strings.Repeat("0", scale-len(fp)), 10)
+	if !ok || !n.IsInt64() {
+		return d, errors.Errorf("invalid decimal: value out of range: '%v'", s)
+	}
+	d.Units = n.Int64()
+	if strings.HasPrefix(s, "-") {
+		d.Units = -d.Units
+	}
+	return d, nil
+}
+
+func numeric(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func (d Decimal) String() string {
+	s := strconv.FormatInt(d.Units, 10)
+	if d.Scale <= 0 {
+		return s
+	}
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	if len(s) <= d.Scale {
+		s = strings.Repeat("0", d.Scale-len(s)+1) + s
+	}
+	return sign + s[:len(s)-d.Scale] + "." + s[len(s)-d.Scale:]
+}
+
+// MarshalJSON codifica el decimal como un número JSON exacto
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalJSON decodifica un número JSON conservando su escala
+func (d *Decimal) UnmarshalJSON(b []byte) error {
+	s := string(b)
+	if s == "null" {
+		return nil
+	}
+	if strings.HasPrefix(s, `"`) {
+		if err := json.Unmarshal(b, &s); err != nil {
+			return errors.Wrap(err, "invalid decimal")
+		}
+	}
+	scale := d.Scale
+	if i := strings.IndexByte(s, '.'); i >= 0 && len(s)-i-1 > scale {
+		scale = len(s) - i - 1
+	}
+	r, err := ParseDecimal(s, scale)
+	if err != nil {
+		return err
+	}
+	*d = r
+	return nil
+}
+
+// DecimalVar recibe decimales de punto fijo con a lo sumo scale decimales
+func DecimalVar(v *Decimal, scale int) TypedParam {
+	return Typed("decimal with "+strconv.Itoa(scale)+" fractional digits", reflect.TypeOf(Decimal{}), func(arg []byte) (interface{}, error) {
+		r, err := ParseDecimal(string(arg), scale)
+		if err != nil {
+			return nil, err
+		}
+		if v != nil {
+			*v = r
+		}
+		return r, nil
+	})
+}
+
+func Decimals(scale int) TypedParam {
+	return DecimalVar(nil, scale)
+}
@@ -0,0 +1,58 @@
+package param
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"reflect"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+var bytesType = reflect.TypeOf([]byte(nil))
+
+// Base64Var recibe bytes codificados en base64 estándar (con padding)
+func Base64Var(v *[]byte) TypedParam {
+	return Typed("base64 bytes", bytesType, func(arg []byte) (interface{}, error) {
+		r, err := base64.StdEncoding.DecodeString(string(arg))
+		if err != nil {
+			return nil, errors.Errorf("invalid base64 bytes: %v", err)
+		}
+		if v != nil {
+			*v = r
+		}
+		return r, nil
+	})
+}
+
+var Base64 = Base64Var(nil)
+
+func HexVar(v *[]byte) TypedParam {
+	return Typed("hexadecimal bytes", bytesType, func(arg []byte) (interface{}, error) {
+		r, err := hex.DecodeString(string(arg))
+		if err != nil {
+			return nil, errors.Errorf("invalid hexadecimal bytes: %v", err)
+		}
+		if v != nil {
+			*v = r
+		}
+		return r, nil
+	})
+}
+
+var Hex = HexVar(nil)
+
+func UUIDVar(v *uuid.UUID) TypedParam {
+	return Typed("UUID", reflect.TypeOf(uuid.UUID{}), func(arg []byte) (interface{}, error) {
+		r, err := uuid.ParseBytes(arg)
+		if err != nil {
+			return nil, errors.Errorf("invalid UUID: '%v'", string(arg))
+		}
+		if v != nil {
+			*v = r
+		}
+		return r, nil
+	})
+}
+
+var UUID = UUIDVar(nil)
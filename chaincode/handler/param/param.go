@@ -1,6 +1,9 @@
 package param
 
-import "reflect"
+import (
+	"fmt"
+	"reflect"
+)
 
 type Parser func(arg []byte) (value interface{}, err error)
 
@@ -54,6 +57,26 @@ func Untyped(tt ...TypedParam) []Param {
 	return pp
 }
 
+// Named devuelve p con otro nombre, que es su clave en los argumentos nombrados
+func Named(name string, p Param) Param {
+	if t, ok := p.(TypedParam); ok {
+		return NamedTyped(name, t)
+	}
+	r := New(name, p.From)
+	if t := traitsOf(p); !t.plain() {
+		r = reshape(r, func(rt *traits) { *rt = t })
+	}
+	return r
+}
+
+func NamedTyped(name string, p TypedParam) TypedParam {
+	r := Typed(name, p.Type(), p.From)
+	if t := traitsOf(p); !t.plain() {
+		r = reshape(r, func(rt *traits) { *rt = t })
+	}
+	return r
+}
+
 func Specialize(p Param, name string, next Transformer) Param {
 	return New(name, combine(p.From, next))
 }
@@ -75,3 +98,16 @@ func combine(p Parser, t Transformer) Parser {
 		return r, nil
 	}
 }
+
+// Of es un TypedParam cuyo tipo de valor T se conoce en tiempo de compilación
+type Of[T any] struct {
+	TypedParam
+}
+
+func As[T any](p TypedParam) Of[T] {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if !p.Type().AssignableTo(t) {
+		panic(fmt.Sprintf("parameter %s of type %s is not assignable to %s", p.Name(), p.Type(), t))
+	}
+	return Of[T]{TypedParam: p}
+}
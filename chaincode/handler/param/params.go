@@ -3,6 +3,7 @@ package param
 import (
 	"reflect"
 	"strconv"
+	"time"
 	"unicode/utf8"
 
 	"github.com/pkg/errors"
@@ -29,10 +30,7 @@ func parseuint64(arg []byte) (uint64, error) {
 	s := string(arg)
 	r, err := strconv.ParseUint(s, 10, 64)
 	if err != nil {
-		if e, ok := err.(*strconv.NumError); ok {
-			err = e.Err
-		}
-		return 0, errors.Errorf("invalid natural integer: %v: '%v'", err, s)
+		return 0, errors.Errorf("invalid natural integer: %v: '%v'", numError(err), s)
 	}
 	return r, nil
 }
@@ -42,8 +40,11 @@ func StringVar(s *string) TypedParam {
 		if !utf8.Valid(bs) {
 			return nil, errors.Errorf("invalid UTF-8 string")
 		}
-		*s = string(bs)
-		return *s, nil
+		v := string(bs)
+		if s != nil {
+			*s = v
+		}
+		return v, nil
 	})
 }
 
@@ -69,3 +70,76 @@ func BoolVar(ref *bool) TypedParam {
 }
 
 var Bool = BoolVar(nil)
+
+func Int64Var(v *int64) TypedParam {
+	return Typed("integer", reflect.TypeOf(int64(0)), func(arg []byte) (interface{}, error) {
+		s := string(arg)
+		r, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return nil, errors.Errorf("invalid integer: %v: '%v'", numError(err), s)
+		}
+		if v != nil {
+			*v = r
+		}
+		return r, nil
+	})
+}
+
+var Int64 = Int64Var(nil)
+
+func Float64Var(v *float64) TypedParam {
+	return Typed("number", reflect.TypeOf(float64(0)), func(arg []byte) (interface{}, error) {
+		s := string(arg)
+		r, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, errors.Errorf("invalid number: %v: '%v'", numError(err), s)
+		}
+		if v != nil {
+			*v = r
+		}
+		return r, nil
+	})
+}
+
+var Float64 = Float64Var(nil)
+
+func numError(err error) error {
+	if e, ok := err.(*strconv.NumError); ok {
+		return e.Err
+	}
+	return err
+}
+
+// TimeVar recibe instantes en formato RFC 3339
+func TimeVar(v *time.Time) TypedParam {
+	return Typed("RFC 3339 time", reflect.TypeOf(time.Time{}), func(arg []byte) (interface{}, error) {
+		s := string(arg)
+		r, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return nil, errors.Errorf("invalid RFC 3339 time: '%v'", s)
+		}
+		if v != nil {
+			*v = r
+		}
+		return r, nil
+	})
+}
+
+var Time = TimeVar(nil)
+
+// DurationVar recibe duraciones en el formato de time.ParseDuration (ej. 1h30m)
+func DurationVar(v *time.Duration) TypedParam {
+	return Typed("duration", reflect.TypeOf(time.Duration(0)), func(arg []byte) (interface{}, error) {
+		s := string(arg)
+		r, err := time.ParseDuration(s)
+		if err != nil {
+			return nil, errors.Errorf("invalid duration: '%v'", s)
+		}
+		if v != nil {
+			*v = r
+		}
+		return r, nil
+	})
+}
+
+var Duration = DurationVar(nil)
@@ -0,0 +1,196 @@
+package param
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+type item struct {
+	ID   uint64 `json:"id"`
+	Name string `json:"name"`
+}
+
+func TestParams(t *testing.T) {
+	tests := []struct {
+		name    string
+		param   TypedParam
+		arg     string
+		want    interface{}
+		wantErr bool
+	}{
+		{"int64", Int64, "-12", int64(-12), false},
+		{"int64 invalid", Int64, "1.5", nil, true},
+		{"float64", Float64, "1.5", 1.5, false},
+		{"float64 invalid", Float64, "x", nil, true},
+		{"decimal", Decimals(2), "-12.3", Decimal{Units: -1230, Scale: 2}, false},
+		{"decimal integer", Decimals(2), "7", Decimal{Units: 700, Scale: 2}, false},
+		{"decimal too precise", Decimals(2), "1.234", nil, true},
+		{"decimal invalid", Decimals(2), "1e3", nil, true},
+		{"decimal signs", Decimals(2), "--1", nil, true},
+		{"time", Time, "2019-05-01T10:00:00Z", time.Date(2019, 5, 1, 10, 0, 0, 0, time.UTC), false},
+		{"time invalid", Time, "2019-05-01", nil, true},
+		{"duration", Duration, "1h30m", 90 * time.Minute, false},
+		{"duration invalid", Duration, "1x", nil, true},
+		{"base64", Base64, "aG9sYQ==", []byte("hola"), false},
+		{"base64 invalid", Base64, "aG9sYQ", nil, true},
+		{"hex", Hex, "686f6c61", []byte("hola"), false},
+		{"hex invalid", Hex, "6", nil, true},
+		{"uuid", UUID, "6ba7b810-9dad-11d1-80b4-00c04fd430c8", uuid.MustParse("6ba7b810-9dad-11d1-80b4-00c04fd430c8"), false},
+		{"uuid invalid", UUID, "bla", nil, true},
+		{"enum", Enum("a", "b"), "b", "b", false},
+		{"enum invalid", Enum("a", "b"), "c", nil, true},
+		{"list", List(Uint64), "[1,2]", []uint64{1, 2}, false},
+		{"list of strings", List(String), `["a","b"]`, []string{"a", "b"}, false},
+		{"list invalid item", List(Uint64), "[1,-2]", nil, true},
+		{"list invalid", List(Uint64), "1", nil, true},
+		{"json", JSON(reflect.TypeOf(&item{})), `{"id":1,"name":"x"}`, &item{ID: 1, Name: "x"}, false},
+		{"json value", JSON(reflect.TypeOf(item{})), `{"id":1}`, item{ID: 1}, false},
+		{"json invalid", JSON(reflect.TypeOf(item{})), `{"id":"x"}`, nil, true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			a := assert.New(t)
+			got, err := tt.param.From([]byte(tt.arg))
+			if tt.wantErr {
+				a.Error(err)
+				return
+			}
+			a.NoError(err)
+			a.Equal(tt.want, got)
+			a.True(reflect.TypeOf(got).AssignableTo(tt.param.Type()))
+		})
+	}
+}
+
+func TestParamVars(t *testing.T) {
+	a := assert.New(t)
+
+	var ns []uint64
+	_, err := ListVar(&ns, Uint64).From([]byte("[3]"))
+	a.NoError(err)
+	a.Equal([]uint64{3}, ns)
+
+	var it item
+	_, err = JSONVar(&it).From([]byte(`{"name":"y"}`))
+	a.NoError(err)
+	a.Equal(item{Name: "y"}, it)
+
+	var d Decimal
+	_, err = DecimalVar(&d, 3).From([]byte("0.05"))
+	a.NoError(err)
+	a.Equal("0.050", d.String())
+	a.Equal("-1.25", Decimal{Units: -125, Scale: 2}.String())
+
+	a.Panics(func() { ListVar(&it, Uint64) })
+	a.Panics(func() { JSONVar(it) })
+}
+
+func TestDecimalJSON(t *testing.T) {
+	a := assert.New(t)
+
+	type amount struct {
+		Value Decimal `json:"value"`
+	}
+	for _, d := range []Decimal{{Units: -125, Scale: 2}, {Units: 100, Scale: 2}, {Units: 7}, {Units: 5, Scale: 3}} {
+		bs, err := json.Marshal(amount{Value: d})
+		a.NoError(err)
+		got := amount{}
+		a.NoError(json.Unmarshal(bs, &got), string(bs))
+		a.Equal(d, got.Value, string(bs))
+	}
+
+	got := amount{Value: Decimal{Scale: 4}}
+	a.NoError(json.Unmarshal([]byte(`{"value":"1.5"}`), &got))
+	a.Equal(Decimal{Units: 15000, Scale: 4}, got.Value)
+
+	a.Error(json.Unmarshal([]byte(`{"value":1e3}`), &got))
+}
+
+func TestConstraints(t *testing.T) {
+	tests := []struct {
+		name  string
+		param Param
+		arg   string
+		rule  string
+	}{
+		{"min ok", Min(Uint64, 2), "2", ""},
+		{"min", Min(Uint64, 2), "1", "min"},
+		{"max ok", Max(Int64, -1), "-2", ""},
+		{"max", Max(Int64, -1), "0", "max"},
+		{"decimal max", Max(Decimals(2), 1.5), "1.51", "max"},
+		{"length ok", Length(String, 1, 3), "abc", ""},
+		{"length short", Length(String, 1, 3), "", "length"},
+		{"length long", Length(String, 1, 3), "abcd", "length"},
+		{"list length", Length(List(Uint64), 1, -1), "[]", "length"},
+		{"pattern ok", Pattern(String, "^[a-z]+$"), "abc", ""},
+		{"pattern", Pattern(String, "^[a-z]+$"), "aBc", "pattern"},
+		{"one of ok", OneOf(Uint64, uint64(1), uint64(3)), "3", ""},
+		{"one of", OneOf(Uint64, uint64(1), uint64(3)), "2", "one-of"},
+		{"combined", Max(Min(Uint64, 1), 5), "6", "max"},
+		{"custom", Custom(String, "even", func(v interface{}) error {
+			if len(v.(string))%2 != 0 {
+				return errors.New("has an odd length")
+			}
+			return nil
+		}), "abc", "even"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			a := assert.New(t)
+			_, err := tt.param.From([]byte(tt.arg))
+			if tt.rule == "" {
+				a.NoError(err)
+				return
+			}
+			ce, ok := AsConstraintError(err)
+			a.True(ok)
+			a.Equal(tt.rule, ce.Rule)
+			a.Contains(err.Error(), "(rule "+tt.rule+")")
+		})
+	}
+}
+
+func TestConstrainedTypes(t *testing.T) {
+	a := assert.New(t)
+	var tp TypedParam = Min(Uint64, 1)
+	a.Equal(Uint64.Type(), tp.Type())
+	o := Max(As[uint64](Uint64), 2)
+	_, err := o.From([]byte("3"))
+	a.Error(err)
+	var p Param = Length(New("name", func(arg []byte) (interface{}, error) { return string(arg), nil }), 1, 1)
+	_, ok := p.(TypedParam)
+	a.False(ok)
+	_, err = p.From([]byte("ab"))
+	a.EqualError(err, "name length 2 is greater than 1 (rule length)")
+	ce, ok := AsConstraintError(err)
+	a.True(ok)
+	a.Equal("name", ce.Param)
+}
+
+func TestConstrainedTraits(t *testing.T) {
+	a := assert.New(t)
+	for _, p := range []TypedParam{
+		Min(Optional(Int64, int64(5)), 0),
+		Optional(Min(Int64, 0), int64(5)),
+	} {
+		def, ok := IsOptional(p)
+		a.True(ok)
+		a.Equal(int64(5), def)
+		_, err := p.From([]byte("-1"))
+		a.Error(err)
+	}
+	o := Max(Variadic(As[uint64](Uint64)), 2)
+	a.True(IsVariadic(o))
+	src, key := SourceOf(Length(FromTransient(String, "secret"), 1, -1))
+	a.Equal(Transient, src)
+	a.Equal("secret", key)
+	a.True(IsJSON(Length(JSON(reflect.TypeOf("")), 1, -1)))
+}
@@ -0,0 +1,129 @@
+package param
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// EnumVar recibe uno de los strings values
+func EnumVar(v *string, values ...string) TypedParam {
+	return Typed("one of "+strings.Join(values, ", "), reflect.TypeOf(""), func(arg []byte) (interface{}, error) {
+		s := string(arg)
+		for _, value := range values {
+			if s == value {
+				if v != nil {
+					*v = s
+				}
+				return s, nil
+			}
+		}
+		return nil, errors.Errorf("invalid value '%v': must be one of %s", s, strings.Join(values, ", "))
+	})
+}
+
+func Enum(values ...string) TypedParam {
+	return EnumVar(nil, values...)
+}
+
+// ListVar recibe un array JSON cuyos elementos se interpretan con p
+func ListVar(ref interface{}, p TypedParam) TypedParam {
+	t := reflect.SliceOf(p.Type())
+	rv := reference(ref, t)
+	return jsonText(Typed("list of "+p.Name(), t, func(arg []byte) (interface{}, error) {
+		raws := []json.RawMessage(nil)
+		if err := json.Unmarshal(arg, &raws); err != nil {
+			return nil, errors.Errorf("invalid list of %s: not a JSON array", p.Name())
+		}
+		r := reflect.MakeSlice(t, 0, len(raws))
+		for i, raw := range raws {
+			v, err := p.From(element(p, raw))
+			if err != nil {
+				return nil, errors.Wrapf(err, "list item %d", i+1)
+			}
+			r = reflect.Append(r, value(v, p.Type()))
+		}
+		if rv.IsValid() {
+			rv.Set(r)
+		}
+		return r.Interface(), nil
+	}))
+}
+
+func List(p TypedParam) TypedParam {
+	return ListVar(nil, p)
+}
+
+// JSONVar recibe un JSON que se decodifica en un nuevo valor asignado a ref
+func JSONVar(ref interface{}) TypedParam {
+	if ref == nil || reflect.TypeOf(ref).Kind() != reflect.Ptr {
+		panic(fmt.Sprintf("JSON parameter reference must be a pointer but is %T", ref))
+	}
+	t := reflect.TypeOf(ref).Elem()
+	return jsonParam(t, reference(ref, t))
+}
+
+// JSON recibe un JSON que se decodifica en un nuevo valor de tipo t
+func JSON(t reflect.Type) TypedParam {
+	return jsonParam(t, reflect.Value{})
+}
+
+func jsonParam(t reflect.Type, rv reflect.Value) TypedParam {
+	return jsonText(Typed("JSON "+t.String(), t, func(arg []byte) (interface{}, error) {
+		var v reflect.Value
+		if t.Kind() == reflect.Ptr {
+			v = reflect.New(t.Elem())
+			if err := json.Unmarshal(arg, v.Interface()); err != nil {
+				return nil, errors.Wrapf(err, "invalid JSON %s", t)
+			}
+		} else {
+			p := reflect.New(t)
+			if err := json.Unmarshal(arg, p.Interface()); err != nil {
+				return nil, errors.Wrapf(err, "invalid JSON %s", t)
+			}
+			v = p.Elem()
+		}
+		if rv.IsValid() {
+			rv.Set(v)
+		}
+		return v.Interface(), nil
+	}))
+}
+
+func jsonText(p TypedParam) TypedParam {
+	return reshape(p, func(t *traits) {
+		t.json = true
+	})
+}
+
+func reference(ref interface{}, t reflect.Type) reflect.Value {
+	if ref == nil {
+		return reflect.Value{}
+	}
+	rv := reflect.ValueOf(ref)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Type().Elem() != t {
+		panic(fmt.Sprintf("parameter reference must be a non nil *%s but is %T", t, ref))
+	}
+	return rv.Elem()
+}
+
+func element(p Param, raw json.RawMessage) []byte {
+	if IsJSON(p) {
+		return raw
+	}
+	s := ""
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return []byte(s)
+	}
+	return raw
+}
+
+func value(v interface{}, t reflect.Type) reflect.Value {
+	if v == nil {
+		return reflect.Zero(t)
+	}
+	return reflect.ValueOf(v)
+}
@@ -0,0 +1,171 @@
+package param
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Source indica de dónde se obtiene el argumento de un param
+type Source int
+
+const (
+	// Positional es un argumento de la llamada
+	Positional Source = iota
+	// Transient es un valor del transient map de la propuesta
+	Transient
+	// FunctionOption es una opción de la llamada (ej. Función?opcion=valor)
+	FunctionOption
+)
+
+func (s Source) String() string {
+	switch s {
+	case Transient:
+		return "transient"
+	case FunctionOption:
+		return "option"
+	}
+	return "positional"
+}
+
+type traits struct {
+	optional bool
+	def      interface{}
+	variadic bool
+	source   Source
+	key      string
+	json     bool
+}
+
+func (t traits) shape() traits {
+	return t
+}
+
+func (t traits) plain() bool {
+	return !t.optional && !t.variadic && t.source == Positional && !t.json
+}
+
+type shaped struct {
+	Param
+	traits
+}
+
+type shapedTyped struct {
+	TypedParam
+	traits
+}
+
+type traitsParam interface {
+	shape() traits
+}
+
+// Optional hace que el argumento de p pueda omitirse, en cuyo caso vale def
+func Optional[P Param](p P, def interface{}) P {
+	if t, ok := typedOf(p); ok {
+		if def == nil {
+			def = reflect.Zero(t.Type()).Interface()
+		} else if !reflect.TypeOf(def).AssignableTo(t.Type()) {
+			panic(fmt.Sprintf("default value %v of parameter %s is not assignable to %s", def, p.Name(), t.Type()))
+		}
+	}
+	return reshape(p, func(t *traits) {
+		t.optional = true
+		t.def = def
+	})
+}
+
+// Variadic hace que p reciba todos los argumentos restantes
+func Variadic[P Param](p P) P {
+	return reshape(p, func(t *traits) {
+		t.variadic = true
+	})
+}
+
+// FromTransient hace que el argumento de p se obtenga de la clave key del transient map
+func FromTransient[P Param](p P, key string) P {
+	return reshape(p, func(t *traits) {
+		t.source = Transient
+		t.key = key
+	})
+}
+
+// FromOption hace que el argumento de p se obtenga de la opción name de la llamada
+func FromOption[P Param](p P, name string) P {
+	return reshape(p, func(t *traits) {
+		t.source = FunctionOption
+		t.key = name
+	})
+}
+
+// IsOptional indica si p es opcional y su valor por defecto
+func IsOptional(p Param) (interface{}, bool) {
+	t := traitsOf(p)
+	return t.def, t.optional
+}
+
+func IsVariadic(p Param) bool {
+	return traitsOf(p).variadic
+}
+
+// IsJSON indica si el argumento de p es un texto JSON
+func IsJSON(p Param) bool {
+	return traitsOf(p).json
+}
+
+// SourceOf devuelve de dónde se obtiene el argumento de p y con qué clave
+func SourceOf(p Param) (Source, string) {
+	t := traitsOf(p)
+	return t.source, t.key
+}
+
+// VariadicType es el tipo del valor de un param variádico tipado
+func VariadicType(p TypedParam) reflect.Type {
+	return reflect.SliceOf(p.Type())
+}
+
+func reshape[P Param](p P, f func(*traits)) P {
+	var q Param = p
+	if s, ok := q.(specializer); ok {
+		q = s.typed()
+	}
+	t := traitsOf(q)
+	f(&t)
+	var r interface{}
+	switch b := base(q).(type) {
+	case TypedParam:
+		r = &shapedTyped{TypedParam: b, traits: t}
+	default:
+		r = &shaped{Param: b, traits: t}
+	}
+	if s, ok := Param(p).(specializer); ok {
+		r = s.specialize(r.(TypedParam))
+	}
+	return r.(P)
+}
+
+func base(p Param) Param {
+	switch s := p.(type) {
+	case *shaped:
+		return s.Param
+	case *shapedTyped:
+		return s.TypedParam
+	}
+	return p
+}
+
+func traitsOf(p Param) traits {
+	if s, ok := p.(specializer); ok {
+		p = s.typed()
+	}
+	if t, ok := p.(traitsParam); ok {
+		return t.shape()
+	}
+	return traits{}
+}
+
+func typedOf(p Param) (TypedParam, bool) {
+	if s, ok := p.(specializer); ok {
+		return s.typed(), true
+	}
+	t, ok := p.(TypedParam)
+	return t, ok
+}
@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/lalloni/fabrikit/chaincode/handler/param"
+	"github.com/lalloni/fabrikit/chaincode/response"
+)
+
+// ArgumentProblem es un problema con el valor de un argumento
+type ArgumentProblem struct {
+	Position int    `json:"position,omitempty"`
+	Name     string `json:"name,omitempty"`
+	Source   string `json:"source,omitempty"`
+	Key      string `json:"key,omitempty"`
+	Rule     string `json:"rule,omitempty"`
+	Message  string `json:"message"`
+	text     string
+}
+
+// ArgumentsError reúne los problemas de todos los argumentos de una llamada
+type ArgumentsError struct {
+	Problems []ArgumentProblem `json:"arguments"`
+}
+
+func (e *ArgumentsError) Error() string {
+	ss := []string(nil)
+	for _, p := range e.Problems {
+		ss = append(ss, p.text)
+	}
+	return strings.Join(ss, "; ")
+}
+
+func (e *ArgumentsError) add(pos int, name string, err error) {
+	p := ArgumentProblem{Position: pos, Name: name, Message: err.Error()}
+	if ce, ok := param.AsConstraintError(err); ok {
+		p.Rule = ce.Rule
+	}
+	if pos > 0 {
+		p.text = errors.Wrapf(err, "%s argument %d", name, pos).Error()
+	} else {
+		p.text = errors.Wrapf(err, "argument %q", name).Error()
+	}
+	e.Problems = append(e.Problems, p)
+}
+
+func (e *ArgumentsError) addSourced(src param.Source, key, name string, err error) {
+	p := ArgumentProblem{Source: src.String(), Key: key, Name: name, Message: err.Error()}
+	if ce, ok := param.AsConstraintError(err); ok {
+		p.Rule = ce.Rule
+	}
+	p.text = errors.Wrapf(err, "%s argument from %s %q", name, src, key).Error()
+	e.Problems = append(e.Problems, p)
+}
+
+func (e *ArgumentsError) err() error {
+	if len(e.Problems) == 0 {
+		return nil
+	}
+	return e
+}
+
+// ArgsResponse es la respuesta a un error de extracción de argumentos
+func ArgsResponse(err error) *response.Response {
+	res := response.BadRequest("%s", err.Error())
+	if aerr, ok := errors.Cause(err).(*ArgumentsError); ok {
+		res.Payload = &response.Payload{Fault: aerr}
+	}
+	return res
+}
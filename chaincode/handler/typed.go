@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"github.com/lalloni/fabrikit/chaincode/context"
+	"github.com/lalloni/fabrikit/chaincode/handler/param"
+	"github.com/lalloni/fabrikit/chaincode/response"
+)
+
+func Func0[R any](function func(*context.Context) R) Handler {
+	return func(ctx *context.Context) *response.Response {
+		if _, err := Extract(ctx); err != nil {
+			return ArgsResponse(err)
+		}
+		return result(ctx, function(ctx))
+	}
+}
+
+func Func1[A, R any](function func(*context.Context, A) R, a param.Of[A]) Handler {
+	return func(ctx *context.Context) *response.Response {
+		args, err := Extract(ctx, a)
+		if err != nil {
+			return ArgsResponse(err)
+		}
+		return result(ctx, function(ctx, args[0].(A)))
+	}
+}
+
+func Func2[A, B, R any](function func(*context.Context, A, B) R, a param.Of[A], b param.Of[B]) Handler {
+	return func(ctx *context.Context) *response.Response {
+		args, err := Extract(ctx, a, b)
+		if err != nil {
+			return ArgsResponse(err)
+		}
+		return result(ctx, function(ctx, args[0].(A), args[1].(B)))
+	}
+}
+
+func Func3[A, B, C, R any](function func(*context.Context, A, B, C) R, a param.Of[A], b param.Of[B], c param.Of[C]) Handler {
+	return func(ctx *context.Context) *response.Response {
+		args, err := Extract(ctx, a, b, c)
+		if err != nil {
+			return ArgsResponse(err)
+		}
+		return result(ctx, function(ctx, args[0].(A), args[1].(B), args[2].(C)))
+	}
+}
@@ -0,0 +1,295 @@
+package handler_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lalloni/fabrikit/chaincode/context"
+	"github.com/lalloni/fabrikit/chaincode/failure"
+	"github.com/lalloni/fabrikit/chaincode/handler"
+	"github.com/lalloni/fabrikit/chaincode/handler/param"
+	"github.com/lalloni/fabrikit/chaincode/response"
+	"github.com/lalloni/fabrikit/chaincode/response/status"
+	"github.com/lalloni/fabrikit/chaincode/router"
+	"github.com/lalloni/fabrikit/chaincode/test"
+)
+
+func TestTypedFunc(t *testing.T) {
+	a := assert.New(t)
+
+	r := router.New()
+	r.SetHandler("f1", nil, handler.Func1(func(_ *context.Context, n uint64) uint64 {
+		return n * 2
+	}, param.As[uint64](param.Uint64)))
+	r.SetHandler("f2", nil, handler.Func2(func(_ *context.Context, s string, b bool) string {
+		return fmt.Sprintf("%s-%v", s, b)
+	}, param.As[string](param.String), param.As[bool](param.Bool)))
+	mock := test.NewMock("cc", r)
+
+	_, res, p, err := test.MockInvoke(t, mock, "f1", "21")
+	a.NoError(err)
+	a.EqualValues(status.OK, res.Status)
+	a.EqualValues(42, p.Content)
+
+	_, res, _, err = test.MockInvoke(t, mock, "f1", "x")
+	a.NoError(err)
+	a.EqualValues(status.BadRequest, res.Status)
+
+	_, res, p, err = test.MockInvoke(t, mock, "f2", "a", "true")
+	a.NoError(err)
+	a.EqualValues(status.OK, res.Status)
+	a.EqualValues("a-true", p.Content)
+
+	a.Panics(func() { param.As[string](param.Uint64) })
+}
+
+func TestNamedArgs(t *testing.T) {
+	a := assert.New(t)
+
+	f := func(_ *context.Context, s string, n uint64) string {
+		return fmt.Sprintf("%s-%d", s, n)
+	}
+	pars := []param.TypedParam{param.NamedTyped("s", param.String), param.NamedTyped("n", param.Uint64)}
+	r := router.New()
+	r.SetHandler("f", nil, handler.MustFunc(f, pars...))
+	r.SetHandler("g", nil, handler.MustFunc(f, pars...), router.WithMiddleware(handler.NamedArgs))
+	mock := test.NewMock("cc", r)
+
+	_, res, p, err := test.MockInvoke(t, mock, "f", "a", "1")
+	a.NoError(err)
+	a.EqualValues(status.OK, res.Status)
+	a.EqualValues("a-1", p.Content)
+
+	_, res, p, err = test.MockInvoke(t, mock, "f?named", map[string]interface{}{"n": 2, "s": "b"})
+	a.NoError(err)
+	a.EqualValues(status.OK, res.Status)
+	a.EqualValues("b-2", p.Content)
+
+	_, res, p, err = test.MockInvoke(t, mock, "g", map[string]interface{}{"n": 3, "s": "c"})
+	a.NoError(err)
+	a.EqualValues(status.OK, res.Status)
+	a.EqualValues("c-3", p.Content)
+
+	_, res, _, err = test.MockInvoke(t, mock, "g", map[string]interface{}{"n": 3})
+	a.NoError(err)
+	a.EqualValues(status.BadRequest, res.Status)
+	a.Contains(res.Message, `missing arguments "s"`)
+}
+
+func TestArgumentProblems(t *testing.T) {
+	a := assert.New(t)
+
+	r := router.New()
+	r.SetHandler("f", nil, handler.Func2(func(_ *context.Context, n uint64, s string) string {
+		return fmt.Sprintf("%s-%d", s, n)
+	}, param.Min(param.As[uint64](param.Uint64), 10), param.Pattern(param.As[string](param.String), "^[a-z]+$")))
+	mock := test.NewMock("cc", r)
+
+	_, res, p, err := test.MockInvoke(t, mock, "f", "1", "X")
+	a.NoError(err)
+	a.EqualValues(status.BadRequest, res.Status)
+	a.Contains(res.Message, "natural integer argument 1: natural integer value 1 is less than 10 (rule min)")
+	a.Contains(res.Message, "string argument 2")
+	a.Equal(map[string]interface{}{
+		"arguments": []interface{}{
+			map[string]interface{}{"position": 1.0, "name": "natural integer", "rule": "min", "message": "natural integer value 1 is less than 10 (rule min)"},
+			map[string]interface{}{"position": 2.0, "name": "string", "rule": "pattern", "message": `string value "X" does not match pattern "^[a-z]+$" (rule pattern)`},
+		},
+	}, p.Fault)
+
+	_, res, p, err = test.MockInvoke(t, mock, "f", "10", "x")
+	a.NoError(err)
+	a.EqualValues(status.OK, res.Status)
+	a.EqualValues("x-10", p.Content)
+}
+
+func TestOptionalAndVariadic(t *testing.T) {
+	a := assert.New(t)
+
+	sum := func(_ *context.Context, base uint64, ns ...uint64) uint64 {
+		for _, n := range ns {
+			base += n
+		}
+		return base
+	}
+	greet := func(_ *context.Context, name string, greeting string) string {
+		return greeting + " " + name
+	}
+	r := router.New()
+	r.SetHandler("sum", nil, handler.MustFunc(sum, param.NamedTyped("base", param.Uint64), param.Variadic(param.NamedTyped("ns", param.Uint64))))
+	r.SetHandler("greet", nil, handler.Func2(greet, param.As[string](param.String), param.Optional(param.As[string](param.String), "hello")))
+	mock := test.NewMock("cc", r)
+
+	_, res, p, err := test.MockInvoke(t, mock, "sum", "1")
+	a.NoError(err)
+	a.EqualValues(status.OK, res.Status)
+	a.EqualValues(1, p.Content)
+
+	_, _, p, err = test.MockInvoke(t, mock, "sum", "1", "2", "3")
+	a.NoError(err)
+	a.EqualValues(6, p.Content)
+
+	_, _, p, err = test.MockInvoke(t, mock, "sum?named", map[string]interface{}{"base": 1, "ns": []int{4, 5}})
+	a.NoError(err)
+	a.EqualValues(10, p.Content)
+
+	_, res, _, err = test.MockInvoke(t, mock, "sum")
+	a.NoError(err)
+	a.EqualValues(status.BadRequest, res.Status)
+	a.Contains(res.Message, "expecting at least 1")
+
+	_, _, p, err = test.MockInvoke(t, mock, "greet", "bob")
+	a.NoError(err)
+	a.EqualValues("hello bob", p.Content)
+
+	_, _, p, err = test.MockInvoke(t, mock, "greet", "bob", "bye")
+	a.NoError(err)
+	a.EqualValues("bye bob", p.Content)
+
+	a.Panics(func() { param.Optional(param.Uint64, "x") })
+}
+
+func TestErrorResults(t *testing.T) {
+	a := assert.New(t)
+
+	errBusy := errors.New("busy")
+	find := func(_ *context.Context, n uint64) (string, error) {
+		switch n {
+		case 0:
+			return "", errors.Wrap(failure.NotFound("thing %d not found", n), "finding")
+		case 1:
+			return "", failure.Conflict("thing %d is locked", n).WithFault(map[string]interface{}{"owner": "bob"})
+		case 2:
+			return "", errBusy
+		case 3:
+			return "", errors.New("boom")
+		}
+		return fmt.Sprint(n), nil
+	}
+	check := func(_ *context.Context, n uint64) error {
+		if n == 0 {
+			return failure.BadRequest("zero")
+		}
+		return nil
+	}
+	busy := func(err error) *response.Response {
+		if errors.Cause(err) == errBusy {
+			return response.StatusWithMessage(503, "try later")
+		}
+		return nil
+	}
+	r := router.New()
+	r.SetHandler("find", nil, handler.MustFunc(find, param.Uint64), router.WithClassifiers(busy))
+	r.SetHandler("check", nil, handler.Func1(check, param.As[uint64](param.Uint64)))
+	r.SetHandler("unclassified", nil, handler.MustFunc(find, param.Uint64))
+	mock := test.NewMock("cc", r)
+
+	_, res, p, err := test.MockInvoke(t, mock, "find", 5)
+	a.NoError(err)
+	a.EqualValues(status.OK, res.Status)
+	a.EqualValues("5", p.Content)
+
+	_, res, _, err = test.MockInvoke(t, mock, "find", 0)
+	a.NoError(err)
+	a.EqualValues(status.NotFound, res.Status)
+	a.Equal("finding: thing 0 not found", res.Message)
+
+	_, res, p, err = test.MockInvoke(t, mock, "find", 1)
+	a.NoError(err)
+	a.EqualValues(status.Conflict, res.Status)
+	a.Equal(map[string]interface{}{"owner": "bob"}, p.Fault)
+
+	_, res, _, err = test.MockInvoke(t, mock, "find", 2)
+	a.NoError(err)
+	a.EqualValues(503, res.Status)
+	a.Equal("try later", res.Message)
+
+	_, res, _, err = test.MockInvoke(t, mock, "unclassified", 2)
+	a.NoError(err)
+	a.EqualValues(status.Error, res.Status)
+
+	_, res, _, err = test.MockInvoke(t, mock, "find", 3)
+	a.NoError(err)
+	a.EqualValues(status.Error, res.Status)
+	a.Equal("boom", res.Message)
+
+	_, res, _, err = test.MockInvoke(t, mock, "check", 0)
+	a.NoError(err)
+	a.EqualValues(status.BadRequest, res.Status)
+
+	_, res, _, err = test.MockInvoke(t, mock, "check", 1)
+	a.NoError(err)
+	a.EqualValues(status.OK, res.Status)
+}
+
+type transientStub struct {
+	*shim.MockStub
+	args      [][]byte
+	transient map[string][]byte
+}
+
+func (s *transientStub) GetArgs() [][]byte {
+	return s.args
+}
+
+func (s *transientStub) GetTransient() (map[string][]byte, error) {
+	return s.transient, nil
+}
+
+func TestSourcedParams(t *testing.T) {
+	a := assert.New(t)
+
+	login := func(_ *context.Context, user string, password string, verbose bool) string {
+		return fmt.Sprintf("%s:%s:%v", user, password, verbose)
+	}
+	h := handler.MustFunc(login,
+		param.String,
+		param.FromTransient(param.Length(param.String, 4, -1), "password"),
+		param.FromOption(param.Optional(param.Bool, false), "verbose"))
+
+	call := func(fn string, tm map[string][]byte, args ...string) *response.Response {
+		stub := &transientStub{MockStub: shim.NewMockStub("cc", nil), transient: tm, args: [][]byte{[]byte(fn)}}
+		for _, arg := range args {
+			stub.args = append(stub.args, []byte(arg))
+		}
+		return h(context.New(stub, "cc", "1"))
+	}
+
+	res := call("login", map[string][]byte{"password": []byte("secret")}, "bob")
+	a.EqualValues(status.OK, res.Status)
+	a.Equal("bob:secret:false", res.Payload.Content)
+
+	res = call("login?verbose=true", map[string][]byte{"password": []byte("secret")}, "bob")
+	a.EqualValues(status.OK, res.Status)
+	a.Equal("bob:secret:true", res.Payload.Content)
+
+	res = call("login?named", map[string][]byte{"password": []byte("secret")}, `{"string":"ann"}`)
+	a.EqualValues(status.OK, res.Status)
+	a.Equal("ann:secret:false", res.Payload.Content)
+
+	res = call("login", nil, "bob")
+	a.EqualValues(status.BadRequest, res.Status)
+	a.Equal(`string argument from transient "password": is required`, res.Message)
+	problem := res.Payload.Fault.(*handler.ArgumentsError).Problems[0]
+	a.Equal("transient", problem.Source)
+	a.Equal("password", problem.Key)
+	a.Equal("is required", problem.Message)
+
+	res = call("login?verbose=x", map[string][]byte{"password": []byte("abc")})
+	a.EqualValues(status.BadRequest, res.Status)
+	a.Contains(res.Message, "argument count mismatch")
+
+	res = call("login?verbose=x", map[string][]byte{"password": []byte("abc")}, "bob")
+	a.EqualValues(status.BadRequest, res.Status)
+	problems := res.Payload.Fault.(*handler.ArgumentsError).Problems
+	a.Len(problems, 2)
+	a.Equal("length", problems[0].Rule)
+	a.Equal("verbose", problems[1].Key)
+
+	_, err := handler.ExtractArgs(nil, param.FromTransient(param.String, "x"))
+	a.Error(err)
+}
@@ -1,8 +1,11 @@
 package crud
 
 import (
+	"reflect"
 	"strings"
 
+	"github.com/pkg/errors"
+
 	auth "github.com/lalloni/fabrikit/chaincode/authorization"
 	"github.com/lalloni/fabrikit/chaincode/context"
 	"github.com/lalloni/fabrikit/chaincode/handler"
@@ -23,21 +26,36 @@ type opt struct {
 	putlist  bool
 	del      bool
 	delrange bool
+	restore  bool
+	purge    bool
+	sweep    bool
+	export   bool
+	import_  bool
+	checkint bool
+	remote   bool
 
-	defaultcheck auth.Check
-	readcheck    auth.Check
-	writecheck   auth.Check
+	defaultcheck check
+	readcheck    check
+	writecheck   check
 
-	getcheck      auth.Check
-	getallcheck   auth.Check
-	getrangecheck auth.Check
-	hascheck      auth.Check
-	putcheck      auth.Check
-	putlistcheck  auth.Check
-	delcheck      auth.Check
-	delrangecheck auth.Check
+	getcheck      check
+	getallcheck   check
+	getrangecheck check
+	hascheck      check
+	putcheck      check
+	putlistcheck  check
+	delcheck      check
+	delrangecheck check
+	restorecheck  check
+	purgecheck    check
+	sweepcheck    check
+	exportcheck   check
+	importcheck   check
+	checkintcheck check
+	remotecheck   check
 
-	validator Validator
+	validator  Validator
+	importmode ImportMode
 
 	id   param.Param
 	item param.Param
@@ -46,29 +64,82 @@ type opt struct {
 
 type Option func(*opt)
 
-func WithGet(b bool) Option      { return func(o *opt) { o.get = b } }
-func WithGetAll(b bool) Option   { return func(o *opt) { o.getall = b } }
-func WithGetRange(b bool) Option { return func(o *opt) { o.getrange = b } }
-func WithHas(b bool) Option      { return func(o *opt) { o.has = b } }
-func WithPut(b bool) Option      { return func(o *opt) { o.put = b } }
-func WithPutList(b bool) Option  { return func(o *opt) { o.putlist = b } }
-func WithDel(b bool) Option      { return func(o *opt) { o.del = b } }
-func WithDelRange(b bool) Option { return func(o *opt) { o.delrange = b } }
-
-func WithDefaultCheck(c auth.Check) Option { return func(o *opt) { o.defaultcheck = c } }
-func WithReadCheck(c auth.Check) Option    { return func(o *opt) { o.readcheck = c } }
-func WithWriteCheck(c auth.Check) Option   { return func(o *opt) { o.writecheck = c } }
-
-func WithGetCheck(c auth.Check) Option      { return func(o *opt) { o.getcheck = c } }
-func WithGetAllCheck(c auth.Check) Option   { return func(o *opt) { o.getallcheck = c } }
-func WithGetRangeCheck(c auth.Check) Option { return func(o *opt) { o.getrangecheck = c } }
-func WithHasCheck(c auth.Check) Option      { return func(o *opt) { o.hascheck = c } }
-func WithPutCheck(c auth.Check) Option      { return func(o *opt) { o.putcheck = c } }
-func WithPutListCheck(c auth.Check) Option  { return func(o *opt) { o.putlistcheck = c } }
-func WithDelCheck(c auth.Check) Option      { return func(o *opt) { o.delcheck = c } }
-func WithDelRangeCheck(c auth.Check) Option { return func(o *opt) { o.delrangecheck = c } }
-
-func WithValidator(v Validator) Option { return func(o *opt) { o.validator = v } }
+func WithGet(b bool) Option       { return func(o *opt) { o.get = b } }
+func WithGetAll(b bool) Option    { return func(o *opt) { o.getall = b } }
+func WithGetRange(b bool) Option  { return func(o *opt) { o.getrange = b } }
+func WithHas(b bool) Option       { return func(o *opt) { o.has = b } }
+func WithPut(b bool) Option       { return func(o *opt) { o.put = b } }
+func WithPutList(b bool) Option   { return func(o *opt) { o.putlist = b } }
+func WithDel(b bool) Option       { return func(o *opt) { o.del = b } }
+func WithDelRange(b bool) Option  { return func(o *opt) { o.delrange = b } }
+func WithRestore(b bool) Option   { return func(o *opt) { o.restore = b } }
+func WithPurge(b bool) Option     { return func(o *opt) { o.purge = b } }
+func WithSweep(b bool) Option     { return func(o *opt) { o.sweep = b } }
+func WithExport(b bool) Option    { return func(o *opt) { o.export = b } }
+func WithImport(b bool) Option    { return func(o *opt) { o.import_ = b } }
+func WithIntegrity(b bool) Option { return func(o *opt) { o.checkint = b } }
+func WithRemote(b bool) Option    { return func(o *opt) { o.remote = b } }
+
+func WithDefaultCheck(c auth.Check, text ...string) Option {
+	return func(o *opt) { o.defaultcheck = described(c, text) }
+}
+func WithReadCheck(c auth.Check, text ...string) Option {
+	return func(o *opt) { o.readcheck = described(c, text) }
+}
+func WithWriteCheck(c auth.Check, text ...string) Option {
+	return func(o *opt) { o.writecheck = described(c, text) }
+}
+
+func WithGetCheck(c auth.Check, text ...string) Option {
+	return func(o *opt) { o.getcheck = described(c, text) }
+}
+func WithGetAllCheck(c auth.Check, text ...string) Option {
+	return func(o *opt) { o.getallcheck = described(c, text) }
+}
+func WithGetRangeCheck(c auth.Check, text ...string) Option {
+	return func(o *opt) { o.getrangecheck = described(c, text) }
+}
+func WithHasCheck(c auth.Check, text ...string) Option {
+	return func(o *opt) { o.hascheck = described(c, text) }
+}
+func WithPutCheck(c auth.Check, text ...string) Option {
+	return func(o *opt) { o.putcheck = described(c, text) }
+}
+func WithPutListCheck(c auth.Check, text ...string) Option {
+	return func(o *opt) { o.putlistcheck = described(c, text) }
+}
+func WithDelCheck(c auth.Check, text ...string) Option {
+	return func(o *opt) { o.delcheck = described(c, text) }
+}
+func WithDelRangeCheck(c auth.Check, text ...string) Option {
+	return func(o *opt) { o.delrangecheck = described(c, text) }
+}
+func WithRestoreCheck(c auth.Check, text ...string) Option {
+	return func(o *opt) { o.restorecheck = described(c, text) }
+}
+func WithPurgeCheck(c auth.Check, text ...string) Option {
+	return func(o *opt) { o.purgecheck = described(c, text) }
+}
+func WithSweepCheck(c auth.Check, text ...string) Option {
+	return func(o *opt) { o.sweepcheck = described(c, text) }
+}
+func WithExportCheck(c auth.Check, text ...string) Option {
+	return func(o *opt) { o.exportcheck = described(c, text) }
+}
+func WithImportCheck(c auth.Check, text ...string) Option {
+	return func(o *opt) { o.importcheck = described(c, text) }
+}
+func WithRemoteCheck(c auth.Check, text ...string) Option {
+	return func(o *opt) { o.remotecheck = described(c, text) }
+}
+
+// WithIntegrityCheck establece el check de la verificación de integridad
+func WithIntegrityCheck(c auth.Check, text ...string) Option {
+	return func(o *opt) { o.checkintcheck = described(c, text) }
+}
+
+func WithValidator(v Validator) Option   { return func(o *opt) { o.validator = v } }
+func WithImportMode(m ImportMode) Option { return func(o *opt) { o.importmode = m } }
 
 func WithIDParam(p param.Param) Option   { return func(o *opt) { o.id = p } }
 func WithItemParam(p param.Param) Option { return func(o *opt) { o.item = p } }
@@ -91,65 +162,189 @@ var Defaults = []Option{
 	WithPutList(true),
 	WithDel(true),
 	WithDelRange(true),
+	WithRemote(true),
 	WithDefaultCheck(auth.Allowed),
 }
 
-func AddHandlers(r router.Router, s *store.Schema, opts ...Option) {
+// AddHandlers agrega al grupo los handlers del schema
+func AddHandlers(r router.Group, s *store.Schema, opts ...Option) {
 	o := &opt{}
 	for _, opt := range opts {
 		opt(o)
 	}
 	name := strings.Title(s.Name())
+	dc := o.defaultcheck
+	if gc := r.DefaultCheck(); gc != nil {
+		dc = check{check: gc}
+	}
+	vt := valueType(s)
 	if o.get {
-		c := pri(o.getcheck, o.readcheck, o.defaultcheck)
-		add(r, "Get"+name, c, GetHandler(s, o.id))
+		c := pri(o.getcheck, o.readcheck, dc)
+		add(r, "Get"+name, c, GetHandler(s, o.id),
+			read("Gets the "+s.Name()+" with the given id", vt),
+			router.WithParam("id", o.id))
 	}
 	if o.getall {
-		c := pri(o.getallcheck, o.readcheck, o.defaultcheck)
-		add(r, "Get"+name+"All", c, GetAllHandler(s))
+		c := pri(o.getallcheck, o.readcheck, dc)
+		add(r, "Get"+name+"All", c, GetAllHandler(s),
+			read("Gets every "+s.Name(), slice(vt)))
 	}
 	if o.getrange {
-		c := pri(o.getrangecheck, o.readcheck, o.defaultcheck)
-		add(r, "Get"+name+"Range", c, GetRangeHandler(s, o.id))
+		c := pri(o.getrangecheck, o.readcheck, dc)
+		add(r, "Get"+name+"Range", c, GetRangeHandler(s, o.id),
+			read("Gets every "+s.Name()+" with an id in the given range", slice(vt)),
+			router.WithParam("from", o.id), router.WithParam("to", o.id))
 	}
 	if o.has {
-		c := pri(o.hascheck, o.readcheck, o.defaultcheck)
-		add(r, "Has"+name, c, HasHandler(s, o.id))
+		c := pri(o.hascheck, o.readcheck, dc)
+		add(r, "Has"+name, c, HasHandler(s, o.id),
+			read("Tells whether a "+s.Name()+" with the given id exists", boolType),
+			router.WithParam("id", o.id))
 	}
 	if o.put {
-		c := pri(o.putcheck, o.writecheck, o.defaultcheck)
-		add(r, "Put"+name, c, PutHandler(s, o.item, o.validator))
+		c := pri(o.putcheck, o.writecheck, dc)
+		add(r, "Put"+name, c, PutHandler(s, o.item, o.validator),
+			write("Creates or replaces a "+s.Name(), nil),
+			router.WithParam("item", o.item))
 	}
 	if o.putlist {
-		c := pri(o.putlistcheck, o.writecheck, o.defaultcheck)
-		add(r, "Put"+name+"List", c, PutListHandler(s, o.list, o.validator))
+		c := pri(o.putlistcheck, o.writecheck, dc)
+		add(r, "Put"+name+"List", c, PutListHandler(s, o.list, o.validator),
+			write("Creates or replaces every "+s.Name()+" in the list returning how many were put", intType),
+			router.WithParam("list", o.list))
 	}
 	if o.del {
-		c := pri(o.delcheck, o.writecheck, o.defaultcheck)
-		add(r, "Del"+name, c, DelHandler(s, o.id))
+		c := pri(o.delcheck, o.writecheck, dc)
+		add(r, "Del"+name, c, DelHandler(s, o.id),
+			write("Deletes the "+s.Name()+" with the given id", nil),
+			router.WithParam("id", o.id))
 	}
 	if o.delrange {
-		c := pri(o.delcheck, o.writecheck, o.defaultcheck)
-		add(r, "Del"+name+"Range", c, DelRangeHandler(s, o.id))
+		c := pri(o.delcheck, o.writecheck, dc)
+		add(r, "Del"+name+"Range", c, DelRangeHandler(s, o.id),
+			write("Deletes every "+s.Name()+" with an id in the given range returning the deleted ones", slice(vt)),
+			router.WithParam("from", o.id), router.WithParam("to", o.id))
+	}
+	if o.restore {
+		c := pri(o.restorecheck, o.writecheck, dc)
+		add(r, "Restore"+name, c, RestoreHandler(s, o.id),
+			write("Restores the deleted "+s.Name()+" with the given id", nil),
+			router.WithParam("id", o.id))
+	}
+	if o.purge {
+		c := pri(o.purgecheck, o.writecheck, dc)
+		add(r, "Purge"+name, c, PurgeHandler(s, o.id),
+			write("Permanently removes the "+s.Name()+" with the given id", nil),
+			router.WithParam("id", o.id))
+	}
+	if o.sweep {
+		c := pri(o.sweepcheck, o.writecheck, dc)
+		add(r, "Sweep"+name, c, SweepHandler(s),
+			write("Deletes the expired "+s.Name()+" among the next limit ones returning their ids", reflect.TypeOf(SweepResult{})),
+			router.WithParam("bookmark", param.String), router.WithParam("limit", param.Uint64))
+	}
+	if o.export {
+		c := pri(o.exportcheck, o.readcheck, dc)
+		add(r, "Export"+name, c, ExportHandler(s),
+			read("Exports a page of "+s.Name()+" records as NDJSON", reflect.TypeOf(ExportPage{})),
+			router.WithParam("bookmark", param.String), router.WithParam("size", param.Uint64))
+	}
+	if o.import_ {
+		c := pri(o.importcheck, o.writecheck, dc)
+		add(r, "Import"+name, c, ImportHandler(s, o.importmode, o.validator),
+			write("Imports "+s.Name()+" records from NDJSON", reflect.TypeOf(ImportResult{})),
+			router.WithParam("records", param.String))
+	}
+	if o.remote {
+		c := pri(o.remotecheck, o.readcheck, dc)
+		for op, h := range RemoteHandlers(s) {
+			add(r, store.RemoteFunction(op, s), c, h, remoteOptions(s, op)...)
+		}
+	}
+	if o.checkint {
+		c := pri(o.checkintcheck)
+		add(r, "Check"+name+"Integrity", c, IntegrityHandler(s),
+			write("Checks and optionally repairs the integrity of a batch of "+s.Name(), reflect.TypeOf(store.IntegrityReport{})),
+			router.WithParam("token", param.String), router.WithParam("limit", param.Uint64),
+			router.WithParam("repair", param.String))
 	}
 }
 
-func pri(cs ...auth.Check) auth.Check {
+type check struct {
+	check auth.Check
+	text  string
+}
+
+func described(c auth.Check, text []string) check {
+	return check{check: c, text: strings.Join(text, " ")}
+}
+
+func pri(cs ...check) check {
 	for _, c := range cs {
-		if c != nil {
+		if c.check != nil {
 			return c
 		}
 	}
-	return auth.Forbidden
+	return check{check: auth.Forbidden}
 }
 
-func add(r router.Router, name string, c auth.Check, h handler.Handler) {
-	r.SetHandler(router.Name(name), c, h)
+func add(r router.Group, name string, c check, h handler.Handler, opts ...router.RouteOption) {
+	if c.text != "" {
+		opts = append(opts, router.WithAuthorization(c.text))
+	}
+	r.SetHandler(router.Name(name), c.check, h, opts...)
 }
 
-func GetHandler(s *store.Schema, id param.Param) handler.Handler {
+var (
+	boolType     = reflect.TypeOf(false)
+	intType      = reflect.TypeOf(0)
+	anySliceType = reflect.TypeOf([]interface{}{})
+)
+
+func read(desc string, res reflect.Type) router.RouteOption {
+	return meta(desc, res, true)
+}
+
+func write(desc string, res reflect.Type) router.RouteOption {
+	return meta(desc, res, false)
+}
+
+func meta(desc string, res reflect.Type, ro bool) router.RouteOption {
+	return router.Options(router.WithDescription(desc), router.WithResponse(res), router.WithReadOnly(ro))
+}
+
+func valueType(s *store.Schema) reflect.Type {
+	v, err := s.Create()
+	if err != nil || v == nil {
+		return nil
+	}
+	return reflect.TypeOf(v)
+}
+
+func slice(t reflect.Type) reflect.Type {
+	if t == nil {
+		return anySliceType
+	}
+	return reflect.SliceOf(t)
+}
+
+// withDeleted hace que h lea también las instancias borradas con la opción deleted
+func withDeleted(h handler.Handler) handler.Handler {
 	return func(c *context.Context) *response.Response {
-		args, err := handler.ExtractArgs(c.Stub.GetArgs()[1:], id)
+		if _, ok := c.Option("deleted"); ok {
+			opts, err := store.Options(c.Stub, "audit", "deleted")
+			if err != nil {
+				return response.BadRequest("invalid function options: %v", err)
+			}
+			c.Store = store.New(c.Stub, opts...)
+		}
+		return h(c)
+	}
+}
+
+func GetHandler(s *store.Schema, id param.Param) handler.Handler {
+	return withDeleted(func(c *context.Context) *response.Response {
+		args, err := handler.Extract(c, param.Named("id", id))
 		if err != nil {
 			return response.BadRequest("invalid %s id: %v", s.Name(), err)
 		}
@@ -161,12 +356,12 @@ func GetHandler(s *store.Schema, id param.Param) handler.Handler {
 			return response.NotFoundWithMessage("%s identified with %v not found", s.Name(), args[0])
 		}
 		return response.OK(v)
-	}
+	})
 }
 
 func GetAllHandler(s *store.Schema) handler.Handler {
-	return func(c *context.Context) *response.Response {
-		_, err := handler.ExtractArgs(c.Stub.GetArgs()[1:]) // no parameters
+	return withDeleted(func(c *context.Context) *response.Response {
+		_, err := handler.Extract(c) // no parameters
 		if err != nil {
 			return response.BadRequest(err.Error())
 		}
@@ -175,12 +370,12 @@ func GetAllHandler(s *store.Schema) handler.Handler {
 			return response.Error("getting %s: %v", s.Name(), err)
 		}
 		return response.OK(v)
-	}
+	})
 }
 
 func GetRangeHandler(s *store.Schema, id param.Param) handler.Handler {
-	return func(c *context.Context) *response.Response {
-		args, err := handler.ExtractArgs(c.Stub.GetArgs()[1:], id, id)
+	return withDeleted(func(c *context.Context) *response.Response {
+		args, err := handler.Extract(c, param.Named("from", id), param.Named("to", id))
 		if err != nil {
 			return response.BadRequest("invalid %s id: %v", s.Name(), err)
 		}
@@ -189,12 +384,12 @@ func GetRangeHandler(s *store.Schema, id param.Param) handler.Handler {
 			return response.Error("getting %s range: %v", s.Name(), err)
 		}
 		return response.OK(v)
-	}
+	})
 }
 
 func PutHandler(s *store.Schema, val param.Param, valid Validator) handler.Handler {
 	return func(c *context.Context) *response.Response {
-		args, err := handler.ExtractArgs(c.Stub.GetArgs()[1:], val)
+		args, err := handler.Extract(c, param.Named("item", val))
 		if err != nil {
 			return response.BadRequest("invalid %s: %v", s.Name(), err)
 		}
@@ -206,15 +401,26 @@ func PutHandler(s *store.Schema, val param.Param, valid Validator) handler.Handl
 		}
 		err = c.Store.PutComposite(s, args[0])
 		if err != nil {
-			return response.Error("putting %s: %v", s.Name(), err)
+			return putError(c, s, err)
+		}
+		if s.GeneratesIdentifier() {
+			id, err := s.ValueIdentifier(args[0])
+			if err != nil {
+				return response.Error("getting %s id: %v", s.Name(), err)
+			}
+			return response.OK(id)
 		}
 		return response.OK(nil)
 	}
 }
 
+func putError(c *context.Context, s *store.Schema, err error) *response.Response {
+	return handler.ErrorResponse(c, errors.Wrapf(err, "putting %s", s.Name()))
+}
+
 func DelHandler(s *store.Schema, id param.Param) handler.Handler {
 	return func(c *context.Context) *response.Response {
-		args, err := handler.ExtractArgs(c.Stub.GetArgs()[1:], id)
+		args, err := handler.Extract(c, param.Named("id", id))
 		if err != nil {
 			return response.BadRequest("invalid %s id: %v", s.Name(), err)
 		}
@@ -235,7 +441,7 @@ func DelHandler(s *store.Schema, id param.Param) handler.Handler {
 
 func DelRangeHandler(s *store.Schema, id param.Param) handler.Handler {
 	return func(c *context.Context) *response.Response {
-		args, err := handler.ExtractArgs(c.Stub.GetArgs()[1:], id, id)
+		args, err := handler.Extract(c, param.Named("from", id), param.Named("to", id))
 		if err != nil {
 			return response.BadRequest("invalid %s id: %v", s.Name(), err)
 		}
@@ -247,9 +453,63 @@ func DelRangeHandler(s *store.Schema, id param.Param) handler.Handler {
 	}
 }
 
-func HasHandler(s *store.Schema, id param.Param) handler.Handler {
+func RestoreHandler(s *store.Schema, id param.Param) handler.Handler {
+	return func(c *context.Context) *response.Response {
+		args, err := handler.Extract(c, param.Named("id", id))
+		if err != nil {
+			return response.BadRequest("invalid %s id: %v", s.Name(), err)
+		}
+		ok, err := c.Store.RestoreComposite(s, args[0])
+		if err != nil {
+			return response.Error("restoring %s: %v", s.Name(), err)
+		}
+		if !ok {
+			return response.NotFoundWithMessage("deleted %s identified with %v not found", s.Name(), args[0])
+		}
+		return response.OK(nil)
+	}
+}
+
+func PurgeHandler(s *store.Schema, id param.Param) handler.Handler {
+	return func(c *context.Context) *response.Response {
+		args, err := handler.Extract(c, param.Named("id", id))
+		if err != nil {
+			return response.BadRequest("invalid %s id: %v", s.Name(), err)
+		}
+		ok, err := c.Store.PurgeComposite(s, args[0])
+		if err != nil {
+			return response.Error("purging %s: %v", s.Name(), err)
+		}
+		if !ok {
+			return response.NotFoundWithMessage("%s identified with %v not found", s.Name(), args[0])
+		}
+		return response.OK(nil)
+	}
+}
+
+// SweepResult es el resultado de una purga de composites expirados
+type SweepResult struct {
+	IDs      []interface{} `json:"ids"`
+	Bookmark string        `json:"bookmark,omitempty"`
+}
+
+func SweepHandler(s *store.Schema) handler.Handler {
 	return func(c *context.Context) *response.Response {
-		args, err := handler.ExtractArgs(c.Stub.GetArgs()[1:], id)
+		args, err := handler.Extract(c, param.Named("bookmark", param.String), param.Named("limit", param.Uint64))
+		if err != nil {
+			return response.BadRequest("invalid %s sweep arguments: %v", s.Name(), err)
+		}
+		ids, bookmark, err := c.Store.SweepComposite(s, args[0].(string), int(args[1].(uint64)))
+		if err != nil {
+			return response.Error("sweeping %s: %v", s.Name(), err)
+		}
+		return response.OK(&SweepResult{IDs: ids, Bookmark: bookmark})
+	}
+}
+
+func HasHandler(s *store.Schema, id param.Param) handler.Handler {
+	return withDeleted(func(c *context.Context) *response.Response {
+		args, err := handler.Extract(c, param.Named("id", id))
 		if err != nil {
 			return response.BadRequest("invalid %s id: %v", s.Name(), err)
 		}
@@ -258,12 +518,12 @@ func HasHandler(s *store.Schema, id param.Param) handler.Handler {
 			return response.Error("getting %s existence: %v", s.Name(), err)
 		}
 		return response.OK(exist)
-	}
+	})
 }
 
 func PutListHandler(s *store.Schema, list param.Param, valid Validator) handler.Handler {
 	return func(c *context.Context) *response.Response {
-		args, err := handler.ExtractArgs(c.Stub.GetArgs()[1:], list)
+		args, err := handler.Extract(c, param.Named("list", list))
 		if err != nil {
 			return response.BadRequest("invalid %s list: %v", s.Name(), err)
 		}
@@ -277,7 +537,7 @@ func PutListHandler(s *store.Schema, list param.Param, valid Validator) handler.
 			}
 			err := c.Store.PutComposite(s, v)
 			if err != nil {
-				return response.Error("putting %s: %v", s.Name(), err)
+				return putError(c, s, err)
 			}
 			count++
 		}
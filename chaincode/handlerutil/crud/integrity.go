@@ -0,0 +1,39 @@
+package crud
+
+import (
+	"github.com/lalloni/fabrikit/chaincode/context"
+	"github.com/lalloni/fabrikit/chaincode/handler"
+	"github.com/lalloni/fabrikit/chaincode/handler/param"
+	"github.com/lalloni/fabrikit/chaincode/response"
+	"github.com/lalloni/fabrikit/chaincode/store"
+)
+
+var repairs = map[string]store.Repair{
+	"":         store.RepairNone,
+	"none":     store.RepairNone,
+	"recreate": store.RepairRecreate,
+	"delete":   store.RepairDelete,
+}
+
+// IntegrityHandler verifica la integridad de los schemas indicados
+func IntegrityHandler(ss ...*store.Schema) handler.Handler {
+	return func(c *context.Context) *response.Response {
+		args, err := handler.Extract(c, param.Named("token", param.String), param.Named("limit", param.Uint64), param.Named("repair", param.String))
+		if err != nil {
+			return response.BadRequest("invalid integrity check arguments: %v", err)
+		}
+		limit := args[1].(uint64)
+		if limit == 0 || limit > store.MaxIntegrityLimit {
+			return response.BadRequest("invalid integrity check limit %d (must be between 1 and %d)", limit, store.MaxIntegrityLimit)
+		}
+		repair, ok := repairs[args[2].(string)]
+		if !ok {
+			return response.BadRequest("invalid integrity repair mode %q", args[2])
+		}
+		report, err := c.Store.CheckIntegrity(ss, args[0].(string), int(limit), repair)
+		if err != nil {
+			return response.Error("checking integrity: %v", err)
+		}
+		return response.OK(report)
+	}
+}
@@ -0,0 +1,147 @@
+package crud
+
+import (
+	"reflect"
+
+	"github.com/lalloni/fabrikit/chaincode/context"
+	"github.com/lalloni/fabrikit/chaincode/handler"
+	"github.com/lalloni/fabrikit/chaincode/handler/param"
+	"github.com/lalloni/fabrikit/chaincode/response"
+	"github.com/lalloni/fabrikit/chaincode/router"
+	"github.com/lalloni/fabrikit/chaincode/store"
+	"github.com/lalloni/fabrikit/chaincode/store/key"
+)
+
+// RemoteHandlers devuelve los handlers de las operaciones de lectura remota
+func RemoteHandlers(s *store.Schema) map[string]handler.Handler {
+	id := param.New("key", func(arg []byte) (interface{}, error) {
+		k, err := key.Parse(string(arg))
+		if err != nil {
+			return nil, err
+		}
+		return s.KeyIdentifier(k)
+	})
+	return map[string]handler.Handler{
+		store.RemoteGet: func(c *context.Context) *response.Response {
+			args, err := handler.Extract(c, id)
+			if err != nil {
+				return response.BadRequest("invalid %s key: %v", s.Name(), err)
+			}
+			v, err := c.Store.GetComposite(s, args[0])
+			if err != nil {
+				return response.Error("getting %s: %v", s.Name(), err)
+			}
+			if v == nil {
+				return response.OK(nil)
+			}
+			rec, err := s.Record(v)
+			if err != nil {
+				return response.Error("getting %s record: %v", s.Name(), err)
+			}
+			return response.OK(rec)
+		},
+		store.RemoteHas: func(c *context.Context) *response.Response {
+			args, err := handler.Extract(c, id)
+			if err != nil {
+				return response.BadRequest("invalid %s key: %v", s.Name(), err)
+			}
+			exist, err := c.Store.HasComposite(s, args[0])
+			if err != nil {
+				return response.Error("getting %s existence: %v", s.Name(), err)
+			}
+			return response.OK(exist)
+		},
+		store.RemoteAll: func(c *context.Context) *response.Response {
+			_, err := handler.Extract(c) // no parameters
+			if err != nil {
+				return response.BadRequest(err.Error())
+			}
+			vs, err := c.Store.GetCompositeAll(s)
+			if err != nil {
+				return response.Error("getting %s: %v", s.Name(), err)
+			}
+			recs, err := records(s, vs)
+			if err != nil {
+				return response.Error("getting %s records: %v", s.Name(), err)
+			}
+			return response.OK(recs)
+		},
+		store.RemoteRange: func(c *context.Context) *response.Response {
+			args, err := handler.Extract(c, param.Named("from", id), param.Named("to", id))
+			if err != nil {
+				return response.BadRequest("invalid %s key: %v", s.Name(), err)
+			}
+			vs, err := c.Store.GetCompositeRange(s, store.R(args[0], args[1]))
+			if err != nil {
+				return response.Error("getting %s range: %v", s.Name(), err)
+			}
+			recs, err := records(s, vs)
+			if err != nil {
+				return response.Error("getting %s records: %v", s.Name(), err)
+			}
+			return response.OK(recs)
+		},
+		store.RemotePage: func(c *context.Context) *response.Response {
+			args, err := handler.Extract(c, param.Named("bookmark", param.String), param.Named("size", param.Uint64))
+			if err != nil {
+				return response.BadRequest("invalid %s page arguments: %v", s.Name(), err)
+			}
+			size := args[1].(uint64)
+			if size == 0 || size > store.MaxPageSize {
+				return response.BadRequest("invalid %s page size %d (must be between 1 and %d)", s.Name(), size, store.MaxPageSize)
+			}
+			vs, bookmark, err := c.Store.GetCompositePage(s, args[0].(string), int(size))
+			if err != nil {
+				return response.Error("getting %s page: %v", s.Name(), err)
+			}
+			recs, err := records(s, vs)
+			if err != nil {
+				return response.Error("getting %s records: %v", s.Name(), err)
+			}
+			return response.OK(&store.RemotePageResult{Records: recs, Bookmark: bookmark})
+		},
+	}
+}
+
+func records(s *store.Schema, vs []interface{}) ([]*store.Record, error) {
+	recs := []*store.Record{}
+	for _, v := range vs {
+		rec, err := s.Record(v)
+		if err != nil {
+			return nil, err
+		}
+		recs = append(recs, rec)
+	}
+	return recs, nil
+}
+
+func remoteOptions(s *store.Schema, op string) []router.RouteOption {
+	recs := reflect.TypeOf([]*store.Record{})
+	switch op {
+	case store.RemoteGet:
+		return []router.RouteOption{
+			read("Gets the "+s.Name()+" record with the given key for remote stores", reflect.TypeOf(&store.Record{})),
+			router.WithParam("key", param.String),
+		}
+	case store.RemoteHas:
+		return []router.RouteOption{
+			read("Tells whether a "+s.Name()+" with the given key exists for remote stores", boolType),
+			router.WithParam("key", param.String),
+		}
+	case store.RemoteAll:
+		return []router.RouteOption{
+			read("Gets every "+s.Name()+" record for remote stores", recs),
+		}
+	case store.RemoteRange:
+		return []router.RouteOption{
+			read("Gets every "+s.Name()+" record with a key in the given range for remote stores", recs),
+			router.WithParam("from", param.String), router.WithParam("to", param.String),
+		}
+	case store.RemotePage:
+		return []router.RouteOption{
+			read("Gets a page of "+s.Name()+" records for remote stores", reflect.TypeOf(&store.RemotePageResult{})),
+			router.WithParam("bookmark", param.String), router.WithParam("size", param.Uint64),
+		}
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package crud
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/lalloni/fabrikit/chaincode/context"
+	"github.com/lalloni/fabrikit/chaincode/handler"
+	"github.com/lalloni/fabrikit/chaincode/handler/param"
+	"github.com/lalloni/fabrikit/chaincode/response"
+	"github.com/lalloni/fabrikit/chaincode/response/status"
+	"github.com/lalloni/fabrikit/chaincode/store"
+)
+
+// ImportMode determina qué hacer al importar un composite que ya existe
+type ImportMode int
+
+const (
+	// ImportUpsert reemplaza las instancias existentes
+	ImportUpsert ImportMode = iota
+	// ImportInsert saltea las instancias existentes
+	ImportInsert
+	// ImportStrict falla ante la primera instancia existente
+	ImportStrict
+)
+
+// ExportPage es una página de composites exportados como NDJSON
+type ExportPage struct {
+	Records  string `json:"records"`
+	Count    int    `json:"count"`
+	Bookmark string `json:"bookmark,omitempty"`
+}
+
+type ImportResult struct {
+	Imported int `json:"imported"`
+	Skipped  int `json:"skipped"`
+}
+
+func ExportHandler(s *store.Schema) handler.Handler {
+	return func(c *context.Context) *response.Response {
+		args, err := handler.Extract(c, param.Named("bookmark", param.String), param.Named("size", param.Uint64))
+		if err != nil {
+			return response.BadRequest("invalid %s export arguments: %v", s.Name(), err)
+		}
+		size := args[1].(uint64)
+		if size == 0 || size > store.MaxPageSize {
+			return response.BadRequest("invalid %s export size %d (must be between 1 and %d)", s.Name(), size, store.MaxPageSize)
+		}
+		vals, bookmark, err := c.Store.GetCompositePage(s, args[0].(string), int(size))
+		if err != nil {
+			return response.Error("exporting %s: %v", s.Name(), err)
+		}
+		b := &strings.Builder{}
+		enc := json.NewEncoder(b)
+		enc.SetEscapeHTML(false)
+		for _, val := range vals {
+			rec, err := s.Record(val)
+			if err != nil {
+				return response.Error("exporting %s: %v", s.Name(), err)
+			}
+			if err := enc.Encode(rec); err != nil {
+				return response.Error("encoding %s record: %v", s.Name(), err)
+			}
+		}
+		return response.OK(&ExportPage{Records: b.String(), Count: len(vals), Bookmark: bookmark})
+	}
+}
+
+func ImportHandler(s *store.Schema, mode ImportMode, valid Validator) handler.Handler {
+	return func(c *context.Context) *response.Response {
+		args, err := handler.Extract(c, param.Named("records", param.String))
+		if err != nil {
+			return response.BadRequest("invalid %s import arguments: %v", s.Name(), err)
+		}
+		res := &ImportResult{}
+		scanner := bufio.NewScanner(strings.NewReader(args[0].(string)))
+		scanner.Buffer(nil, len(args[0].(string))+1)
+		line := 0
+		for scanner.Scan() {
+			line++
+			bs := bytes.TrimSpace(scanner.Bytes())
+			if len(bs) == 0 {
+				continue
+			}
+			rec := &store.Record{}
+			if err := json.Unmarshal(bs, rec); err != nil {
+				return response.BadRequest("invalid %s record at line %d: %v", s.Name(), line, err)
+			}
+			val, err := s.FromRecord(rec)
+			if err != nil {
+				return response.BadRequest("invalid %s record at line %d: %v", s.Name(), line, err)
+			}
+			if mode != ImportUpsert {
+				id, err := s.ValueIdentifier(val)
+				if err != nil {
+					return response.BadRequest("invalid %s record at line %d: %v", s.Name(), line, err)
+				}
+				exist, err := c.Store.HasComposite(s, id)
+				if err != nil {
+					return response.Error("checking %s existence: %v", s.Name(), err)
+				}
+				if exist {
+					if mode == ImportStrict {
+						return response.StatusWithMessage(status.Conflict, "%s identified with %v already exists", s.Name(), id)
+					}
+					res.Skipped++
+					continue
+				}
+			}
+			if valid != nil {
+				if r := valid(c, val); r != nil {
+					return r
+				}
+			}
+			if err := c.Store.PutComposite(s, val); err != nil {
+				return putError(c, s, err)
+			}
+			res.Imported++
+		}
+		if err := scanner.Err(); err != nil {
+			return response.BadRequest("reading %s records: %v", s.Name(), err)
+		}
+		return response.OK(res)
+	}
+}
@@ -0,0 +1,149 @@
+// Package invocation implementa la invocación de funciones de otros chaincodes
+package invocation
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/pkg/errors"
+
+	"github.com/lalloni/fabrikit/chaincode/response/status"
+)
+
+// Invocation describe la invocación de una función de otro chaincode
+type Invocation struct {
+	Chaincode string
+	// Channel vacío es el canal de la transacción actual
+	Channel  string
+	Function string
+	Options  map[string]string
+	// Args que no son []byte ni string se codifican como JSON
+	Args []interface{}
+}
+
+// InvocationError es el error de una función invocada que responde con un status no OK
+type InvocationError struct {
+	Chaincode string
+	Function  string
+	Status    int32
+	Message   string
+	Fault     json.RawMessage
+}
+
+func (e *InvocationError) Error() string {
+	return fmt.Sprintf("invoking chaincode %q function %q: status %d: %s", e.Chaincode, e.Function, e.Status, e.Message)
+}
+
+// DecodeFault decodifica el fault de la respuesta en v
+func (e *InvocationError) DecodeFault(v interface{}) error {
+	if len(e.Fault) == 0 {
+		return nil
+	}
+	return errors.Wrap(json.Unmarshal(e.Fault, v), "decoding invocation fault")
+}
+
+type BadRequestError struct{ *InvocationError }
+type ForbiddenError struct{ *InvocationError }
+type NotFoundError struct{ *InvocationError }
+type ConflictError struct{ *InvocationError }
+
+func invocationError(e *InvocationError) error {
+	switch e.Status {
+	case status.BadRequest:
+		return &BadRequestError{e}
+	case status.Forbidden:
+		return &ForbiddenError{e}
+	case status.NotFound:
+		return &NotFoundError{e}
+	case status.Conflict:
+		return &ConflictError{e}
+	default:
+		return e
+	}
+}
+
+type envelope struct {
+	Content         json.RawMessage `json:"content,omitempty"`
+	ContentEncoding string          `json:"content-encoding,omitempty"`
+	Fault           json.RawMessage `json:"fault,omitempty"`
+}
+
+// Invoke invoca la función descripta por inv y decodifica su respuesta en content
+func Invoke(stub shim.ChaincodeStubInterface, log *shim.ChaincodeLogger, inv *Invocation, content interface{}, fault interface{}) error {
+	args, err := inv.arguments()
+	if err != nil {
+		return err
+	}
+	log.Debugf("invoking chaincode %q on channel %q function %q", inv.Chaincode, inv.Channel, inv.Function)
+	res := stub.InvokeChaincode(inv.Chaincode, args, inv.Channel)
+	if res.Status >= shim.ERRORTHRESHOLD {
+		ierr := &InvocationError{
+			Chaincode: inv.Chaincode,
+			Function:  inv.Function,
+			Status:    res.Status,
+			Message:   res.Message,
+		}
+		// el payload de un chaincode sin fabrikit puede no ser un envelope
+		env := &envelope{}
+		if len(res.Payload) > 0 && json.Unmarshal(res.Payload, env) == nil {
+			ierr.Fault = env.Fault
+		}
+		if fault != nil {
+			if err := ierr.DecodeFault(fault); err != nil {
+				log.Warningf("invoking chaincode %q function %q: %v", inv.Chaincode, inv.Function, err)
+			}
+		}
+		return invocationError(ierr)
+	}
+	env := &envelope{}
+	if len(res.Payload) > 0 {
+		if err := json.Unmarshal(res.Payload, env); err != nil {
+			return errors.Wrapf(err, "decoding chaincode %q function %q response payload", inv.Chaincode, inv.Function)
+		}
+	}
+	if content == nil || len(env.Content) == 0 {
+		return nil
+	}
+	if bs, ok := content.(*[]byte); ok && env.ContentEncoding != "base64" {
+		s := ""
+		if err := json.Unmarshal(env.Content, &s); err != nil {
+			return errors.Wrapf(err, "decoding chaincode %q function %q response content", inv.Chaincode, inv.Function)
+		}
+		*bs = []byte(s)
+		return nil
+	}
+	if err := json.Unmarshal(env.Content, content); err != nil {
+		return errors.Wrapf(err, "decoding chaincode %q function %q response content into %s", inv.Chaincode, inv.Function, reflect.TypeOf(content))
+	}
+	return nil
+}
+
+func (inv *Invocation) arguments() ([][]byte, error) {
+	fun := inv.Function
+	if len(inv.Options) > 0 {
+		values := url.Values{}
+		for k, v := range inv.Options {
+			values.Set(k, v)
+		}
+		fun += "?" + values.Encode()
+	}
+	args := [][]byte{[]byte(fun)}
+	for i, arg := range inv.Args {
+		switch v := arg.(type) {
+		case []byte:
+			args = append(args, v)
+		case string:
+			args = append(args, []byte(v))
+		default:
+			bs, err := json.Marshal(v)
+			if err != nil {
+				return nil, errors.Wrapf(err, "encoding chaincode %q function %q argument %d", inv.Chaincode, inv.Function, i+1)
+			}
+			args = append(args, bs)
+		}
+	}
+	return args, nil
+}
@@ -0,0 +1,55 @@
+package chaincode
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"runtime/debug"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/hyperledger/fabric/protos/peer"
+
+	"github.com/lalloni/fabrikit/chaincode/context"
+	"github.com/lalloni/fabrikit/chaincode/response"
+	"github.com/lalloni/fabrikit/chaincode/response/status"
+)
+
+// Panic es el fault de la respuesta a un handler que generó un panic
+type Panic struct {
+	// FaultID identifica el panic en los logs de todos los endorsers
+	FaultID string `json:"fault-id"`
+	Stack   string `json:"stack,omitempty"`
+}
+
+// protect convierte un panic durante la transacción en la respuesta res
+func (c *cc) protect(stub shim.ChaincodeStubInterface, ctx **context.Context, res *peer.Response) {
+	p := recover()
+	if p == nil {
+		return
+	}
+	txid := stub.GetTxID()
+	stack := string(debug.Stack())
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s\n%v", txid, p)))
+	fault := &Panic{FaultID: hex.EncodeToString(sum[:8])}
+	logger := c.log
+	if *ctx != nil {
+		logger = (*ctx).Logger((*ctx).Function())
+	}
+	logger.Errorf("recovered panic in tx %s with fault id %s: %v\n%s", txid, fault.FaultID, p, stack)
+	msg := fmt.Sprintf("internal error (fault id %s)", fault.FaultID)
+	*res = shim.Error(msg)
+	if *ctx == nil {
+		return
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			logger.Errorf("recovered panic building response for fault id %s: %v", fault.FaultID, p)
+		}
+	}()
+	if _, debug := (*ctx).Option("debug"); debug && c.panicstack {
+		fault.Stack = stack
+	}
+	r := response.StatusWithFault(status.Error, fault)
+	r.Message = msg
+	*res = c.response(*ctx, logger, r, 0)
+}
@@ -14,6 +14,7 @@ type Payload struct {
 	Client          *Client      `json:"client,omitempty"`
 	Chaincode       *Chaincode   `json:"chaincode,omitempty"`
 	Transaction     *Transaction `json:"transaction,omitempty"`
+	Metrics         *Metrics     `json:"metrics,omitempty"`
 	Content         interface{}  `json:"content,omitempty"`
 	ContentEncoding string       `json:"content-encoding,omitempty"`
 	Fault           interface{}  `json:"fault,omitempty"`
@@ -29,6 +30,19 @@ type Chaincode struct {
 	Version string `json:"version,omitempty"`
 }
 
+// Metrics son las mediciones de la ejecución de la transacción
+type Metrics struct {
+	HandlerTime        string `json:"handler-time,omitempty"`
+	StatesRead         int    `json:"states-read"`
+	RangeScans         int    `json:"range-scans"`
+	KeysWritten        int    `json:"keys-written"`
+	KeysDeleted        int    `json:"keys-deleted"`
+	PlainBytesWritten  int    `json:"plain-bytes-written"`
+	StoredBytesWritten int    `json:"stored-bytes-written"`
+	StoredBytesRead    int    `json:"stored-bytes-read"`
+	PlainBytesRead     int    `json:"plain-bytes-read"`
+}
+
 type Transaction struct {
 	ID       string `json:"id,omitempty"`
 	Function string `json:"function,omitempty"`
@@ -10,6 +10,7 @@ const (
 	BadRequest = 400 // RFC 7231, 6.5.1
 	Forbidden  = 403 // RFC 7231, 6.5.3
 	NotFound   = 404 // RFC 7231, 6.5.4
+	Conflict   = 409 // RFC 7231, 6.5.8
 
 	Error          = 500 // RFC 7231, 6.6.1
 	NotImplemented = 501 // RFC 7231, 6.6.2
@@ -0,0 +1,191 @@
+package router
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/lalloni/fabrikit/chaincode/handler/param"
+)
+
+// API es la descripción de las funciones de un router al estilo OpenAPI
+type API struct {
+	OpenAPI string              `json:"openapi"`
+	Info    APIInfo             `json:"info"`
+	Paths   map[string]*APIPath `json:"paths"`
+}
+
+type APIInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type APIPath struct {
+	Post *APIOperation `json:"post"`
+}
+
+type APIOperation struct {
+	OperationID   string                  `json:"operationId"`
+	Description   string                  `json:"description,omitempty"`
+	Arguments     []*APIArgument          `json:"x-arguments"`
+	Responses     map[string]*APIResponse `json:"responses"`
+	Authorization string                  `json:"x-authorization,omitempty"`
+	ReadOnly      bool                    `json:"x-read-only"`
+}
+
+type APIArgument struct {
+	Name        string     `json:"name"`
+	Position    int        `json:"position,omitempty"`
+	In          string     `json:"in,omitempty"`
+	Key         string     `json:"x-key,omitempty"`
+	Description string     `json:"description,omitempty"`
+	Required    bool       `json:"required"`
+	Variadic    bool       `json:"x-variadic,omitempty"`
+	Schema      JSONSchema `json:"schema,omitempty"`
+}
+
+type APIResponse struct {
+	Description string               `json:"description"`
+	Content     map[string]*APIMedia `json:"content,omitempty"`
+}
+
+type APIMedia struct {
+	Schema JSONSchema `json:"schema"`
+}
+
+// JSONSchema es un JSON schema en su representación genérica
+type JSONSchema map[string]interface{}
+
+// Describe construye la descripción de la API de las funciones del router
+func Describe(r Router, title, version string) *API {
+	api := &API{
+		OpenAPI: "3.0.3",
+		Info:    APIInfo{Title: title, Version: version},
+		Paths:   map[string]*APIPath{},
+	}
+	for _, n := range r.Functions() {
+		m := r.Metadata(n)
+		op := &APIOperation{
+			OperationID:   string(n),
+			Description:   m.Description,
+			Arguments:     []*APIArgument{},
+			Authorization: m.Authorization,
+			ReadOnly:      m.ReadOnly,
+			Responses: map[string]*APIResponse{
+				"200":     {Description: "success"},
+				"default": {Description: "failure with an optional fault"},
+			},
+		}
+		pos := 0
+		for _, p := range m.Params {
+			arg := &APIArgument{Name: p.Name}
+			if src, key := param.SourceOf(p.Param); p.Param != nil && src != param.Positional {
+				arg.In, arg.Key = src.String(), key
+			} else {
+				pos++
+				arg.Position = pos
+			}
+			if p.Param != nil {
+				def, optional := param.IsOptional(p.Param)
+				arg.Description = p.Param.Name()
+				arg.Variadic = param.IsVariadic(p.Param)
+				arg.Required = !optional && !arg.Variadic
+				if tp, ok := p.Param.(param.TypedParam); ok {
+					arg.Schema = TypeSchema(tp.Type())
+					if optional && arg.Schema != nil {
+						arg.Schema["default"] = def
+					}
+				}
+			}
+			op.Arguments = append(op.Arguments, arg)
+		}
+		if m.Response != nil {
+			op.Responses["200"].Content = map[string]*APIMedia{
+				"application/json": {Schema: TypeSchema(m.Response)},
+			}
+		}
+		api.Paths["/"+string(n)] = &APIPath{Post: op}
+	}
+	return api
+}
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	rawType       = reflect.TypeOf(json.RawMessage{})
+	marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+// TypeSchema devuelve el JSON schema de la codificación JSON del tipo t
+func TypeSchema(t reflect.Type) JSONSchema {
+	return typeSchema(t, map[reflect.Type]bool{})
+}
+
+func typeSchema(t reflect.Type, seen map[reflect.Type]bool) JSONSchema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return JSONSchema{"type": "string", "format": "date-time"}
+	case t == rawType:
+		return JSONSchema{}
+	case t.Implements(marshalerType) || reflect.PtrTo(t).Implements(marshalerType):
+		return JSONSchema{}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return JSONSchema{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return JSONSchema{"type": "integer", "format": "int64"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return JSONSchema{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return JSONSchema{"type": "number"}
+	case reflect.String:
+		return JSONSchema{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return JSONSchema{"type": "string", "format": "byte"}
+		}
+		return JSONSchema{"type": "array", "items": typeSchema(t.Elem(), seen)}
+	case reflect.Map:
+		return JSONSchema{"type": "object", "additionalProperties": typeSchema(t.Elem(), seen)}
+	case reflect.Struct:
+		if seen[t] {
+			return JSONSchema{"type": "object"} // tipo recursivo
+		}
+		seen[t] = true
+		defer delete(seen, t)
+		props := JSONSchema{}
+		structProperties(t, props, seen)
+		return JSONSchema{"type": "object", "properties": props}
+	}
+	return JSONSchema{}
+}
+
+func structProperties(t reflect.Type, props JSONSchema, seen map[reflect.Type]bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := strings.Split(f.Tag.Get("json"), ",")
+		if tag[0] == "-" || (f.PkgPath != "" && !f.Anonymous) {
+			continue
+		}
+		ft := f.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && tag[0] == "" && ft.Kind() == reflect.Struct {
+			structProperties(ft, props, seen)
+			continue
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+		name := f.Name
+		if tag[0] != "" {
+			name = tag[0]
+		}
+		props[name] = typeSchema(f.Type, seen)
+	}
+}
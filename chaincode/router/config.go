@@ -1,6 +1,11 @@
 package router
 
 import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
 	"github.com/lalloni/fabrikit/chaincode/authorization"
 	"github.com/lalloni/fabrikit/chaincode/handler"
 )
@@ -9,9 +14,113 @@ type Route struct {
 	Name    string
 	Check   authorization.Check
 	Handler handler.Handler
+	Options []RouteOption
 }
 
 type Config struct {
 	Init *Route
 	Funs []Route
 }
+
+type configOptions struct {
+	check      authorization.Check
+	handler    handler.Handler
+	middleware []Middleware
+	api        bool
+}
+
+type ConfigOption func(*configOptions)
+
+// WithDefaultCheck establece el check de las rutas que no lo definen
+func WithDefaultCheck(c authorization.Check) ConfigOption {
+	return func(o *configOptions) { o.check = c }
+}
+
+// WithDefaultHandler establece el handler de las rutas que no lo definen
+func WithDefaultHandler(h handler.Handler) ConfigOption {
+	return func(o *configOptions) { o.handler = h }
+}
+
+// WithGlobalMiddleware agrega middlewares globales al router construido
+func WithGlobalMiddleware(mws ...Middleware) ConfigOption {
+	return func(o *configOptions) { o.middleware = append(o.middleware, mws...) }
+}
+
+// WithAPI agrega al router construido la función APIFunction
+func WithAPI() ConfigOption {
+	return func(o *configOptions) { o.api = true }
+}
+
+// ConfigError describe todos los problemas encontrados en una configuración
+type ConfigError struct {
+	Problems []string
+}
+
+func (e *ConfigError) Error() string {
+	return fmt.Sprintf("invalid router config: %s", strings.Join(e.Problems, "; "))
+}
+
+var anonymous = regexp.MustCompile(`^func\d+$`)
+
+// FromConfig construye y valida un router a partir de la configuración
+func FromConfig(cfg *Config, opts ...ConfigOption) (Router, error) {
+	if cfg == nil {
+		return nil, &ConfigError{Problems: []string{"config is nil"}}
+	}
+	o := &configOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	problems := []string(nil)
+	problem := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+	r := New()
+	r.Use(o.middleware...)
+	if cfg.Init != nil {
+		// a diferencia de las funciones, init puede no tener handler
+		h := HandlerDefault(HandlerDefault(cfg.Init.Handler, o.handler), handler.SuccessHandler)
+		r.SetInitHandler(CheckDefault(cfg.Init.Check, o.check), h, cfg.Init.Options...)
+	}
+	names := map[Name]int{}
+	for i, route := range cfg.Funs {
+		h := HandlerDefault(route.Handler, o.handler)
+		desc := fmt.Sprintf("function route %d", i)
+		if route.Name != "" {
+			desc = fmt.Sprintf("function route %d (%q)", i, route.Name)
+		}
+		if h == nil {
+			problem("%s has no handler", desc)
+			continue
+		}
+		if route.Name == "" && route.Handler == nil {
+			problem("%s has no name and uses the default handler", desc)
+			continue
+		}
+		n := NameDefault(route.Name, route.Handler)
+		if n == "" || anonymous.MatchString(string(n)) {
+			problem("%s has no name and its handler is anonymous", desc)
+			continue
+		}
+		if o.api && n == APIFunction {
+			problem("%s has the name %q reserved for the API description", desc, n)
+			continue
+		}
+		if j, dup := names[n]; dup {
+			problem("%s has the same name %q as function route %d", desc, n, j)
+			continue
+		}
+		names[n] = i
+		r.SetHandler(n, CheckDefault(route.Check, o.check), h, route.Options...)
+	}
+	if o.api {
+		r.SetHandler(APIFunction, CheckDefault(nil, o.check), APIHandler(r),
+			WithDescription("Describes every function of the chaincode"),
+			WithResponse(reflect.TypeOf(API{})),
+			WithReadOnly(true))
+	}
+	if len(problems) > 0 {
+		return nil, &ConfigError{Problems: problems}
+	}
+	return r, nil
+}
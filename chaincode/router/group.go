@@ -0,0 +1,48 @@
+package router
+
+import (
+	"github.com/lalloni/fabrikit/chaincode/authorization"
+	"github.com/lalloni/fabrikit/chaincode/handler"
+)
+
+// Group permite definir rutas con un prefijo, check por defecto y middlewares
+type Group interface {
+	SetHandler(Name, authorization.Check, handler.Handler, ...RouteOption)
+	// Group crea un grupo anidado
+	Group(prefix string, check authorization.Check, mws ...Middleware) Group
+	// DefaultCheck es el check de las rutas definidas sin check
+	DefaultCheck() authorization.Check
+}
+
+type group struct {
+	router     *router
+	prefix     string
+	check      authorization.Check
+	middleware []Middleware
+}
+
+func (r *router) Group(prefix string, check authorization.Check, mws ...Middleware) Group {
+	return &group{router: r, prefix: prefix, check: check, middleware: mws}
+}
+
+func (r *router) DefaultCheck() authorization.Check {
+	return nil
+}
+
+func (g *group) SetHandler(n Name, ch authorization.Check, h handler.Handler, opts ...RouteOption) {
+	opts = append([]RouteOption{WithMiddleware(g.middleware...)}, opts...)
+	g.router.SetHandler(Name(g.prefix)+n, CheckDefault(ch, g.check), h, opts...)
+}
+
+func (g *group) Group(prefix string, check authorization.Check, mws ...Middleware) Group {
+	return &group{
+		router:     g.router,
+		prefix:     g.prefix + prefix,
+		check:      CheckDefault(check, g.check),
+		middleware: append(append([]Middleware(nil), g.middleware...), mws...),
+	}
+}
+
+func (g *group) DefaultCheck() authorization.Check {
+	return g.check
+}
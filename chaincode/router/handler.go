@@ -6,11 +6,40 @@ import (
 	"github.com/lalloni/fabrikit/chaincode/response"
 )
 
+// FunctionInfo describe una función en el catálogo de FunctionsHandler
+type FunctionInfo struct {
+	Name        Name   `json:"name"`
+	Description string `json:"description,omitempty"`
+	ReadOnly    bool   `json:"read-only"`
+}
+
+// FunctionsHandler devuelve los nombres de las funciones del router
 func FunctionsHandler(r Router) handler.Handler {
 	return func(ctx *context.Context) *response.Response {
 		if err := handler.CheckArgsCount(ctx, 0); err != nil {
 			return response.BadRequest(err.Error())
 		}
+		if _, details := ctx.Option("details"); details {
+			fs := []FunctionInfo{}
+			for _, n := range r.Functions() {
+				m := r.Metadata(n)
+				fs = append(fs, FunctionInfo{Name: n, Description: m.Description, ReadOnly: m.ReadOnly})
+			}
+			return response.OK(fs)
+		}
 		return response.OK(r.Functions())
 	}
 }
+
+// APIFunction es el nombre con el que WithAPI agrega APIHandler al router
+const APIFunction Name = "API"
+
+// APIHandler devuelve la descripción de todas las funciones del router
+func APIHandler(r Router) handler.Handler {
+	return func(ctx *context.Context) *response.Response {
+		if err := handler.CheckArgsCount(ctx, 0); err != nil {
+			return response.BadRequest(err.Error())
+		}
+		return response.OK(Describe(r, ctx.Name(), ctx.Version()))
+	}
+}
@@ -0,0 +1,56 @@
+package router
+
+import (
+	"reflect"
+
+	"github.com/lalloni/fabrikit/chaincode/handler/param"
+)
+
+// Metadata describe una ruta para su documentación
+type Metadata struct {
+	Description string
+	Params      []ParamInfo
+	// Response es el tipo del contenido de las respuestas exitosas
+	Response reflect.Type
+	// Authorization describe en palabras la autorización requerida
+	Authorization string
+	ReadOnly      bool
+}
+
+// ParamInfo describe un argumento posicional de una ruta
+type ParamInfo struct {
+	Name  string
+	Param param.Param
+}
+
+func WithDescription(d string) RouteOption {
+	return func(r *route) {
+		r.meta.Description = d
+	}
+}
+
+// WithParam agrega la descripción del siguiente argumento de la ruta
+func WithParam(name string, p param.Param) RouteOption {
+	return func(r *route) {
+		r.meta.Params = append(r.meta.Params, ParamInfo{Name: name, Param: p})
+	}
+}
+
+func WithResponse(t reflect.Type) RouteOption {
+	return func(r *route) {
+		r.meta.Response = t
+	}
+}
+
+func WithAuthorization(text string) RouteOption {
+	return func(r *route) {
+		r.meta.Authorization = text
+	}
+}
+
+// WithReadOnly marca la ruta como de sólo lectura
+func WithReadOnly(b bool) RouteOption {
+	return func(r *route) {
+		r.meta.ReadOnly = b
+	}
+}
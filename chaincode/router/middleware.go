@@ -0,0 +1,41 @@
+package router
+
+import (
+	"github.com/lalloni/fabrikit/chaincode/handler"
+)
+
+// Middleware envuelve un handler agregándole comportamiento
+type Middleware func(handler.Handler) handler.Handler
+
+// Chain compone los middlewares de manera que el primero sea el más externo
+func Chain(mws ...Middleware) Middleware {
+	return func(h handler.Handler) handler.Handler {
+		for i := len(mws) - 1; i >= 0; i-- {
+			h = mws[i](h)
+		}
+		return h
+	}
+}
+
+type RouteOption func(*route)
+
+// WithMiddleware agrega middlewares a la ruta
+func WithMiddleware(mws ...Middleware) RouteOption {
+	return func(r *route) {
+		r.middleware = append(r.middleware, mws...)
+	}
+}
+
+// Options combina varias opciones de ruta en una
+func Options(opts ...RouteOption) RouteOption {
+	return func(r *route) {
+		for _, opt := range opts {
+			opt(r)
+		}
+	}
+}
+
+// WithClassifiers agrega a la ruta clasificadores de errores (ver handler.Classify)
+func WithClassifiers(cs ...handler.Classifier) RouteOption {
+	return WithMiddleware(handler.Classify(cs...))
+}
@@ -5,7 +5,10 @@ import (
 	"sort"
 
 	"github.com/lalloni/fabrikit/chaincode/authorization"
+	"github.com/lalloni/fabrikit/chaincode/context"
 	"github.com/lalloni/fabrikit/chaincode/handler"
+	"github.com/lalloni/fabrikit/chaincode/response"
+	"github.com/lalloni/fabrikit/chaincode/store"
 )
 
 type Name string
@@ -15,22 +18,70 @@ func (n *Name) String() string {
 }
 
 type Router interface {
+	Group
 	InitHandler() handler.Handler
-	SetInitHandler(authorization.Check, handler.Handler)
+	SetInitHandler(authorization.Check, handler.Handler, ...RouteOption)
 	Handler(Name) handler.Handler
-	SetHandler(Name, authorization.Check, handler.Handler)
 	Functions() []Name
+	// Metadata devuelve la metadata de la función o nil si no existe
+	Metadata(Name) *Metadata
+	// Use agrega middlewares que se aplican a todas las rutas
+	Use(...Middleware)
 }
 
 func New() Router {
 	return &router{
-		functionHandlers: map[string]handler.Handler{},
+		functionHandlers: map[string]*route{},
 	}
 }
 
 type router struct {
-	initHandler      handler.Handler
-	functionHandlers map[string]handler.Handler
+	initHandler      *route
+	functionHandlers map[string]*route
+	middleware       []Middleware
+}
+
+type route struct {
+	handler    handler.Handler
+	middleware []Middleware
+	meta       Metadata
+}
+
+func newRoute(action string, ch authorization.Check, h handler.Handler, opts []RouteOption) *route {
+	if h == nil {
+		h = handler.SuccessHandler
+	}
+	if ch != nil {
+		h = authorization.Handler(action, ch, h)
+	}
+	r := &route{handler: h, meta: Metadata{Authorization: authorization.Describe(ch)}}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+func (r *router) Use(mws ...Middleware) {
+	r.middleware = append(r.middleware, mws...)
+}
+
+func (r *router) wrap(rt *route) handler.Handler {
+	if rt == nil {
+		return nil
+	}
+	h := Chain(rt.middleware...)(rt.handler)
+	if rt.meta.ReadOnly {
+		h = readOnly(h)
+	}
+	return Chain(r.middleware...)(h)
+}
+
+func readOnly(h handler.Handler) handler.Handler {
+	return func(ctx *context.Context) *response.Response {
+		ctx.Stub = store.ReadOnlyStub(ctx.Stub)
+		ctx.Store = store.ReadOnly(ctx.Store)
+		return h(ctx)
+	}
 }
 
 func (r *router) Functions() []Name {
@@ -47,31 +98,26 @@ func (r *router) Functions() []Name {
 }
 
 func (r *router) InitHandler() handler.Handler {
-	return r.initHandler
+	return r.wrap(r.initHandler)
 }
 
-func (r *router) SetInitHandler(ch authorization.Check, h handler.Handler) {
-	if h == nil {
-		h = handler.SuccessHandler
-	}
-	if ch != nil {
-		r.initHandler = authorization.Handler("init", ch, h)
-	} else {
-		r.initHandler = h
-	}
+func (r *router) SetInitHandler(ch authorization.Check, h handler.Handler, opts ...RouteOption) {
+	r.initHandler = newRoute("init", ch, h, opts)
 }
 
 func (r *router) Handler(n Name) handler.Handler {
-	return r.functionHandlers[n.String()]
+	return r.wrap(r.functionHandlers[n.String()])
 }
 
-func (r *router) SetHandler(n Name, ch authorization.Check, h handler.Handler) {
-	if h == nil {
-		h = handler.SuccessHandler
-	}
-	if ch != nil {
-		r.functionHandlers[n.String()] = authorization.Handler(fmt.Sprintf("invoke function %q", n), ch, h)
-	} else {
-		r.functionHandlers[n.String()] = h
+func (r *router) Metadata(n Name) *Metadata {
+	rt := r.functionHandlers[n.String()]
+	if rt == nil {
+		return nil
 	}
+	m := rt.meta
+	return &m
+}
+
+func (r *router) SetHandler(n Name, ch authorization.Check, h handler.Handler, opts ...RouteOption) {
+	r.functionHandlers[n.String()] = newRoute(fmt.Sprintf("invoke function %q", n), ch, h, opts)
 }
@@ -1,16 +1,23 @@
 package router_test
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/hyperledger/fabric/protos/peer"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/lalloni/fabrikit/chaincode/authorization"
 	"github.com/lalloni/fabrikit/chaincode/context"
+	"github.com/lalloni/fabrikit/chaincode/handler"
+	"github.com/lalloni/fabrikit/chaincode/handler/param"
+	"github.com/lalloni/fabrikit/chaincode/handlerutil/crud"
 	"github.com/lalloni/fabrikit/chaincode/response"
 	"github.com/lalloni/fabrikit/chaincode/response/status"
 	"github.com/lalloni/fabrikit/chaincode/router"
+	"github.com/lalloni/fabrikit/chaincode/store"
+	"github.com/lalloni/fabrikit/chaincode/storeutil"
 	"github.com/lalloni/fabrikit/chaincode/test"
 )
 
@@ -99,3 +106,270 @@ func TestRouter(t *testing.T) {
 	a.NotEmpty(tx)
 	a.EqualValues("ok!", p.Content)
 }
+
+func TestMiddleware(t *testing.T) {
+	a := assert.New(t)
+
+	trace := []string{}
+	mw := func(name string) router.Middleware {
+		return func(h handler.Handler) handler.Handler {
+			return func(ctx *context.Context) *response.Response {
+				trace = append(trace, name+">")
+				res := h(ctx)
+				trace = append(trace, "<"+name)
+				return res
+			}
+		}
+	}
+	h := func(_ *context.Context) *response.Response {
+		trace = append(trace, "h")
+		return response.OK(nil)
+	}
+
+	r := router.New()
+	r.Use(mw("g1"))
+	r.SetHandler("f", nil, h, router.WithMiddleware(mw("r1"), mw("r2")))
+	r.SetHandler("x", authorization.Forbidden, h, router.WithMiddleware(mw("r")))
+	r.Use(mw("g2"))
+	stub := test.NewMock("test", r)
+
+	_, res, _, err := test.MockInvoke(t, stub, "f")
+	a.NoError(err)
+	a.EqualValues(status.OK, res.Status)
+	a.Equal([]string{"g1>", "g2>", "r1>", "r2>", "h", "<r2", "<r1", "<g2", "<g1"}, trace)
+
+	trace = []string{}
+	_, res, _, err = test.MockInvoke(t, stub, "x")
+	a.NoError(err)
+	a.EqualValues(status.Forbidden, res.Status)
+	a.Equal([]string{"g1>", "g2>", "r>", "<r", "<g2", "<g1"}, trace)
+}
+
+func TestGroup(t *testing.T) {
+	a := assert.New(t)
+
+	trace := []string{}
+	mw := func(name string) router.Middleware {
+		return func(h handler.Handler) handler.Handler {
+			return func(ctx *context.Context) *response.Response {
+				trace = append(trace, name)
+				return h(ctx)
+			}
+		}
+	}
+	ok := func(_ *context.Context) *response.Response {
+		return response.OK(nil)
+	}
+
+	r := router.New()
+	admin := r.Group("Admin", authorization.Forbidden, mw("admin"))
+	admin.SetHandler("Reset", nil, ok)
+	admin.SetHandler("Ping", authorization.Allowed, ok)
+	audit := admin.Group("Audit", nil, mw("audit"))
+	audit.SetHandler("List", nil, ok, router.WithMiddleware(mw("list")))
+	crud.AddHandlers(r.Group("", authorization.Forbidden), schema, crud.WithDefaults(), crud.WithIDParam(param.Uint64))
+	stub := test.NewMock("test", r)
+
+	a.Contains(r.Functions(), router.Name("AdminReset"))
+	a.Contains(r.Functions(), router.Name("AdminAuditList"))
+
+	_, res, _, err := test.MockInvoke(t, stub, "AdminReset")
+	a.NoError(err)
+	a.EqualValues(status.Forbidden, res.Status)
+
+	_, res, _, err = test.MockInvoke(t, stub, "AdminPing")
+	a.NoError(err)
+	a.EqualValues(status.OK, res.Status)
+
+	trace = []string{}
+	_, res, _, err = test.MockInvoke(t, stub, "AdminAuditList")
+	a.NoError(err)
+	a.EqualValues(status.Forbidden, res.Status)
+	a.Equal([]string{"admin", "audit", "list"}, trace)
+
+	_, res, _, err = test.MockInvoke(t, stub, "GetThing", 1)
+	a.NoError(err)
+	a.EqualValues(status.Forbidden, res.Status)
+}
+
+type thing struct {
+	ID uint64 `json:"id,omitempty"`
+}
+
+var schema = store.MustPrepare(store.Composite{
+	Name:            "thing",
+	Creator:         func() interface{} { return &thing{} },
+	KeyBaseName:     "thing",
+	IdentifierField: "ID",
+	IdentifierKey:   storeutil.Uint64Key("thing"),
+	KeyIdentifier:   storeutil.Uint64Identifier(0),
+})
+
+func PingHandler(_ *context.Context) *response.Response {
+	return response.OK("pong")
+}
+
+func TestFromConfig(t *testing.T) {
+	a := assert.New(t)
+
+	r, err := router.FromConfig(&router.Config{
+		Funs: []router.Route{
+			{Handler: PingHandler},
+			{Name: "Secret", Handler: PingHandler, Check: authorization.Forbidden},
+			{Name: "Open"},
+		},
+	}, router.WithDefaultCheck(authorization.Allowed), router.WithDefaultHandler(handler.SuccessHandler))
+	a.NoError(err)
+	a.Equal([]router.Name{"Open", "Ping", "Secret"}, r.Functions())
+	stub := test.NewMock("test", r)
+
+	_, res, p, err := test.MockInvoke(t, stub, "Ping")
+	a.NoError(err)
+	a.EqualValues(status.OK, res.Status)
+	a.EqualValues("pong", p.Content)
+
+	_, res, _, err = test.MockInvoke(t, stub, "Secret")
+	a.NoError(err)
+	a.EqualValues(status.Forbidden, res.Status)
+
+	_, err = router.FromConfig(&router.Config{
+		Init: &router.Route{},
+		Funs: []router.Route{
+			{Handler: PingHandler},
+			{Name: "Ping", Handler: PingHandler},
+			{Name: "Missing"},
+			{Handler: func(*context.Context) *response.Response { return nil }},
+		},
+	})
+	a.Error(err)
+	a.IsType(&router.ConfigError{}, err)
+	a.Len(err.(*router.ConfigError).Problems, 3)
+
+	r, err = router.FromConfig(&router.Config{Init: &router.Route{Check: authorization.Allowed}})
+	a.NoError(err)
+	a.NotNil(r.InitHandler())
+
+	_, err = router.FromConfig(nil)
+	a.IsType(&router.ConfigError{}, err)
+}
+
+func TestAPI(t *testing.T) {
+	a := assert.New(t)
+
+	r := router.New()
+	r.SetHandler("Ping", authorization.Allowed, PingHandler,
+		router.WithDescription("Answers pong"),
+		router.WithParam("times", param.Uint64),
+		router.WithResponse(reflect.TypeOf("")),
+		router.WithAuthorization("anyone"),
+		router.WithReadOnly(true))
+	r.SetHandler("API", authorization.Allowed, router.APIHandler(r))
+	crud.AddHandlers(r, schema, crud.WithDefaults(), crud.WithIDParam(param.Uint64),
+		crud.WithReadCheck(authorization.Allowed, "any client"))
+
+	m := r.Metadata("Ping")
+	a.NotNil(m)
+	a.Equal("Answers pong", m.Description)
+	a.Nil(r.Metadata("Missing"))
+
+	api := router.Describe(r, "test", "1")
+	a.Len(api.Paths, len(r.Functions()))
+	op := api.Paths["/Ping"].Post
+	a.Equal("Ping", op.OperationID)
+	a.Equal("anyone", op.Authorization)
+	a.True(op.ReadOnly)
+	a.Equal(router.JSONSchema{"type": "integer", "minimum": 0}, op.Arguments[0].Schema)
+	a.Equal("natural integer", op.Arguments[0].Description)
+
+	op = api.Paths["/GetThing"].Post
+	a.True(op.ReadOnly)
+	a.Equal("id", op.Arguments[0].Name)
+	a.Equal(router.JSONSchema{
+		"type":       "object",
+		"properties": router.JSONSchema{"id": router.JSONSchema{"type": "integer", "minimum": 0}},
+	}, op.Responses["200"].Content["application/json"].Schema)
+	a.Equal("any client", op.Authorization)
+	a.False(api.Paths["/PutThing"].Post.ReadOnly)
+	a.Equal("anyone", api.Paths["/PutThing"].Post.Authorization)
+
+	stub := test.NewMock("test", r)
+	_, res, p, err := test.MockInvoke(t, stub, "API")
+	a.NoError(err)
+	a.EqualValues(status.OK, res.Status)
+	doc := p.Content.(map[string]interface{})
+	a.Equal(map[string]interface{}{"title": "test", "version": "test"}, doc["info"])
+	a.Contains(doc["paths"], "/GetThingAll")
+	get := doc["paths"].(map[string]interface{})["/GetThing"].(map[string]interface{})["post"].(map[string]interface{})
+	a.Equal("any client", get["x-authorization"])
+}
+
+func TestWithAPI(t *testing.T) {
+	a := assert.New(t)
+
+	r, err := router.FromConfig(&router.Config{
+		Funs: []router.Route{{Handler: PingHandler}},
+	}, router.WithDefaultCheck(authorization.Allowed), router.WithAPI())
+	a.NoError(err)
+	a.Equal([]router.Name{router.APIFunction, "Ping"}, r.Functions())
+	a.True(r.Metadata(router.APIFunction).ReadOnly)
+	stub := test.NewMock("test", r)
+
+	_, res, p, err := test.MockInvoke(t, stub, string(router.APIFunction))
+	a.NoError(err)
+	a.EqualValues(status.OK, res.Status, res.Message)
+	doc := p.Content.(map[string]interface{})
+	a.Contains(doc["paths"], "/Ping")
+	a.Contains(doc["paths"], "/API")
+
+	_, err = router.FromConfig(&router.Config{
+		Funs: []router.Route{{Name: "API", Handler: PingHandler}},
+	}, router.WithAPI())
+	a.IsType(&router.ConfigError{}, err)
+}
+
+func TestReadOnly(t *testing.T) {
+	a := assert.New(t)
+
+	var stubErr, storeErr error
+	write := func(ctx *context.Context) *response.Response {
+		stubErr = ctx.Stub.PutState("x", []byte("1"))
+		storeErr = ctx.Store.PutComposite(schema, &thing{ID: 1})
+		return response.OK(nil)
+	}
+	r := router.New()
+	r.SetHandler("Query", authorization.Allowed, write, router.WithReadOnly(true), router.WithDescription("Must not write"))
+	r.SetHandler("Write", authorization.Allowed, write)
+	r.SetHandler("Functions", authorization.Allowed, router.FunctionsHandler(r))
+	crud.AddHandlers(r, schema, crud.WithDefaults(), crud.WithIDParam(param.Uint64))
+	stub := test.NewMock("test", r)
+
+	_, res, _, err := test.MockInvoke(t, stub, "Query")
+	a.NoError(err)
+	a.EqualValues(status.OK, res.Status)
+	a.Equal(store.ErrReadOnly, errors.Cause(stubErr))
+	a.Equal(store.ErrReadOnly, errors.Cause(storeErr))
+	a.EqualError(stubErr, `putting state "x": store is read only`)
+
+	_, res, _, err = test.MockInvoke(t, stub, "Write")
+	a.NoError(err)
+	a.EqualValues(status.OK, res.Status)
+	a.NoError(stubErr)
+	a.NoError(storeErr)
+
+	_, res, p, err := test.MockInvoke(t, stub, "GetThing", 1)
+	a.NoError(err)
+	a.EqualValues(status.OK, res.Status)
+	a.EqualValues(map[string]interface{}{"id": 1.0}, p.Content)
+
+	_, res, p, err = test.MockInvoke(t, stub, "Functions")
+	a.NoError(err)
+	a.EqualValues(status.OK, res.Status)
+	a.Contains(p.Content, "Query")
+
+	_, res, p, err = test.MockInvoke(t, stub, "Functions?details")
+	a.NoError(err)
+	a.EqualValues(status.OK, res.Status)
+	a.Contains(p.Content, map[string]interface{}{"name": "Query", "description": "Must not write", "read-only": true})
+	a.Contains(p.Content, map[string]interface{}{"name": "Write", "read-only": false})
+	a.Contains(p.Content, map[string]interface{}{"name": "GetThing", "description": "Gets the thing with the given id", "read-only": true})
+}
@@ -0,0 +1,100 @@
+package store
+
+import (
+	"time"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim/ext/cid"
+	"github.com/pkg/errors"
+
+	"github.com/lalloni/fabrikit/chaincode/store/key"
+)
+
+// Audit es la metadata de auditoría de un composite
+type Audit struct {
+	Created  *AuditEntry `json:"created,omitempty"`
+	Modified *AuditEntry `json:"modified,omitempty"`
+}
+
+type AuditEntry struct {
+	TxID    string    `json:"txid,omitempty"`
+	Time    time.Time `json:"time,omitempty"`
+	MSPID   string    `json:"mspid,omitempty"`
+	Subject string    `json:"subject,omitempty"`
+}
+
+func (cc *Schema) AuditKey(valkey *key.Key) *key.Key {
+	return valkey.Tagged(auditTag)
+}
+
+func (cc *Schema) IsAuditKey(key *key.Key) bool {
+	return key.Tag.Name == auditTag
+}
+
+func (ss *simplestore) GetCompositeAudit(s *Schema, id interface{}) (*Audit, error) {
+	valkey, err := s.IdentifierKey(id)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	audit := &Audit{}
+	ok, err := ss.internalGetValue(s.AuditKey(valkey), audit)
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting composite %q with key %q audit", s.Name(), valkey)
+	}
+	if !ok {
+		return nil, nil
+	}
+	return audit, nil
+}
+
+// touchAudit registra la modificación actual en el audit del composite
+func (ss *simplestore) touchAudit(s *Schema, valkey *key.Key) error {
+	if !s.composite.Audit {
+		return nil
+	}
+	entry, err := ss.auditEntry()
+	if err != nil {
+		return errors.Wrapf(err, "getting composite %q audit entry", s.Name())
+	}
+	k := s.AuditKey(valkey)
+	audit := &Audit{}
+	ok, err := ss.internalGetValue(k, audit)
+	if err != nil {
+		return errors.Wrapf(err, "getting composite %q with key %q audit", s.Name(), valkey)
+	}
+	if !ok || audit.Created == nil {
+		audit.Created = entry
+	}
+	audit.Modified = entry
+	if err := ss.internalPutValue(k, audit); err != nil {
+		return errors.Wrapf(err, "putting composite %q with key %q audit", s.Name(), valkey)
+	}
+	return nil
+}
+
+// auditEntry calcula una única vez por transacción la entrada de auditoría
+func (ss *simplestore) auditEntry() (*AuditEntry, error) {
+	ss.checkTx()
+	if ss.audit != nil {
+		return ss.audit, nil
+	}
+	t, err := ss.now()
+	if err != nil {
+		return nil, err
+	}
+	entry := &AuditEntry{TxID: ss.stub.GetTxID(), Time: t}
+	id, err := cid.New(ss.stub)
+	if err != nil {
+		ss.log.Warningf("getting client identity for audit in tx %s: %v", ss.stub.GetTxID(), err)
+	} else {
+		if entry.MSPID, err = id.GetMSPID(); err != nil {
+			ss.log.Warningf("getting client mspid for audit in tx %s: %v", ss.stub.GetTxID(), err)
+		}
+		if cert, err := id.GetX509Certificate(); err != nil {
+			ss.log.Warningf("getting client certificate for audit in tx %s: %v", ss.stub.GetTxID(), err)
+		} else if cert != nil {
+			entry.Subject = cert.Subject.String()
+		}
+	}
+	ss.audit = entry
+	return entry, nil
+}
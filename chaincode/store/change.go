@@ -0,0 +1,34 @@
+package store
+
+const (
+	OperationPut     = "put"
+	OperationPatch   = "patch"
+	OperationDelete  = "delete"
+	OperationRestore = "restore"
+	OperationPurge   = "purge"
+	OperationExpire  = "expire"
+)
+
+// Change es una modificación hecha sobre un composite durante la transacción
+type Change struct {
+	Schema    string      `json:"schema,omitempty"`
+	ID        interface{} `json:"id,omitempty"`
+	Operation string      `json:"operation,omitempty"`
+	Member    string      `json:"member,omitempty"`
+	Value     interface{} `json:"value,omitempty"`
+}
+
+func (ss *simplestore) Changes() []Change {
+	return ss.changes
+}
+
+func (ss *simplestore) recordChange(s *Schema, id interface{}, op, member string, val interface{}) {
+	if !s.composite.Events {
+		return
+	}
+	c := Change{Schema: s.name, ID: id, Operation: op, Member: member}
+	if s.composite.EventValues {
+		c.Value = val
+	}
+	ss.changes = append(ss.changes, c)
+}
@@ -1,6 +1,8 @@
 package store
 
 import (
+	"time"
+
 	"github.com/lalloni/fabrikit/chaincode/store/key"
 )
 
@@ -23,39 +25,51 @@ type Item struct {
 }
 
 type Composite struct {
-	Name             string
-	Creator          CreatorFunc
-	Copier           CopierFunc
-	IdentifierField  string
-	IdentifierGetter GetterFunc
-	IdentifierSetter SetterFunc
-	IdentifierKey    KeyFunc
-	KeyIdentifier    ValFunc
-	KeyBaseName      string
-	Singletons       []Singleton
-	Collections      []Collection
-	KeepRoot         bool
+	Name                string
+	Creator             CreatorFunc
+	Copier              CopierFunc
+	IdentifierField     string
+	IdentifierGetter    GetterFunc
+	IdentifierSetter    SetterFunc
+	IdentifierGenerator GeneratorFunc
+	IdentifierKey       KeyFunc
+	KeyIdentifier       ValFunc
+	KeyBaseName         string
+	Singletons          []Singleton
+	Collections         []Collection
+	KeepRoot            bool
+	Validations         []Rule
+	Events              bool
+	EventValues         bool
+	Audit               bool
+	AuditField          string
+	SoftDelete          bool
+	TTL                 time.Duration
+	ExpiryGetter        GetterFunc
+	EndorsementPolicy   PolicyFunc
 }
 
 type Singleton struct {
-	Tag     string
-	Field   string
-	Creator CreatorFunc
-	Getter  GetterFunc
-	Setter  SetterFunc
-	Clear   MutatorFunc
-	schema  *Schema
+	Tag         string
+	Field       string
+	Creator     CreatorFunc
+	Getter      GetterFunc
+	Setter      SetterFunc
+	Clear       MutatorFunc
+	Validations []Rule
+	schema      *Schema
 }
 
 type Collection struct {
 	Tag         string
 	Field       string
-	Creator    CreatorFunc
+	Creator     CreatorFunc
 	Getter      GetterFunc
 	Setter      SetterFunc
 	Clear       MutatorFunc
-	Collector  CollectorFunc
-	Enumerator EnumeratorFunc
+	Collector   CollectorFunc
+	Enumerator  EnumeratorFunc
 	ItemCreator CreatorFunc
+	Validations []Rule
 	schema      *Schema
 }
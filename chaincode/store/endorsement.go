@@ -0,0 +1,102 @@
+package store
+
+import (
+	"github.com/hyperledger/fabric/core/chaincode/shim/ext/statebased"
+	"github.com/pkg/errors"
+
+	"github.com/lalloni/fabrikit/chaincode/store/key"
+)
+
+// PolicyFunc calcula la política de endorsement de las claves de un valor
+type PolicyFunc func(val interface{}) ([]byte, error)
+
+// OrgsPolicy requiere el endorsement de las organizaciones que devuelve getter
+func OrgsPolicy(role statebased.RoleType, getter GetterFunc) PolicyFunc {
+	return func(val interface{}) ([]byte, error) {
+		var orgs []string
+		switch v := getter(val).(type) {
+		case nil:
+		case string:
+			if v != "" {
+				orgs = []string{v}
+			}
+		case []string:
+			orgs = v
+		default:
+			return nil, errors.Errorf("policy organizations getter returned %T instead of string or []string", v)
+		}
+		if len(orgs) == 0 {
+			return nil, nil
+		}
+		ep, err := statebased.NewStateEP(nil)
+		if err != nil {
+			return nil, errors.Wrap(err, "creating state endorsement policy")
+		}
+		if err := ep.AddOrgs(role, orgs...); err != nil {
+			return nil, errors.Wrap(err, "adding organizations to state endorsement policy")
+		}
+		return ep.Policy()
+	}
+}
+
+// PolicyOrgs devuelve las organizaciones requeridas por una política
+func PolicyOrgs(policy []byte) ([]string, error) {
+	if policy == nil {
+		return nil, nil
+	}
+	ep, err := statebased.NewStateEP(policy)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing state endorsement policy")
+	}
+	return ep.ListOrgs(), nil
+}
+
+func (cc *Schema) endorsed() bool {
+	return cc.composite.EndorsementPolicy != nil
+}
+
+func (cc *Schema) Policy(val interface{}) (policy []byte, err error) {
+	defer func() {
+		p := recover()
+		if p != nil {
+			err = errors.Errorf("getting composite %q endorsement policy: %v", cc.name, p)
+		}
+	}()
+	return cc.composite.EndorsementPolicy(val)
+}
+
+// GetCompositePolicy devuelve la política de endorsement del testigo del composite
+func (ss *simplestore) GetCompositePolicy(s *Schema, id interface{}) ([]byte, error) {
+	valkey, err := s.IdentifierKey(id)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	wk := s.KeyWitness(valkey).StringUsing(ss.sep)
+	policy, err := ss.stub.GetStateValidationParameter(wk)
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting composite %q witness %q endorsement policy", s.Name(), wk)
+	}
+	return policy, nil
+}
+
+func (ss *simplestore) endorse(s *Schema, policy []byte, keys []*key.Key) error {
+	for _, k := range keys {
+		ks := k.StringUsing(ss.sep)
+		if err := ss.stub.SetStateValidationParameter(ks, policy); err != nil {
+			return errors.Wrapf(err, "setting composite %q key %q endorsement policy", s.Name(), ks)
+		}
+	}
+	return nil
+}
+
+// inheritPolicy aplica a las claves la política vigente de la instancia
+func (ss *simplestore) inheritPolicy(s *Schema, id interface{}, keys []*key.Key) error {
+	if !s.endorsed() {
+		return nil
+	}
+	policy, err := ss.GetCompositePolicy(s, id)
+	if err != nil || policy == nil {
+		return err
+	}
+	return ss.endorse(s, policy, keys)
+}
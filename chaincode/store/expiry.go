@@ -0,0 +1,154 @@
+package store
+
+import (
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/pkg/errors"
+
+	"github.com/lalloni/fabrikit/chaincode/store/key"
+)
+
+func (cc *Schema) expires() bool {
+	return cc.composite.TTL > 0 || cc.composite.ExpiryGetter != nil
+}
+
+// now devuelve el timestamp de la transacción
+func (ss *simplestore) now() (time.Time, error) {
+	ss.checkTx()
+	if ss.txtime != nil {
+		return *ss.txtime, nil
+	}
+	ts, err := ss.stub.GetTxTimestamp()
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, "getting transaction timestamp")
+	}
+	t, err := ptypes.Timestamp(ts)
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, "converting transaction timestamp")
+	}
+	ss.txtime = &t
+	return t, nil
+}
+
+// checkTx descarta los datos calculados para una transacción anterior
+func (ss *simplestore) checkTx() {
+	if txid := ss.stub.GetTxID(); txid != ss.txid {
+		ss.txid = txid
+		ss.txtime = nil
+		ss.audit = nil
+		ss.txseq = 0
+		ss.seqs = nil
+		ss.metrics = Metrics{}
+	}
+}
+
+func (ss *simplestore) expiry(s *Schema, val interface{}) (exp *time.Time, err error) {
+	if getter := s.composite.ExpiryGetter; getter != nil {
+		defer func() {
+			p := recover()
+			if p != nil {
+				err = errors.Errorf("getting composite %q expiry: %v", s.name, p)
+			}
+		}()
+		switch v := getter(val).(type) {
+		case nil:
+			return nil, nil
+		case *time.Time:
+			return v, nil
+		case time.Time:
+			if v.IsZero() {
+				return nil, nil
+			}
+			return &v, nil
+		default:
+			return nil, errors.Errorf("composite %q expiry getter returned %T instead of time.Time", s.name, v)
+		}
+	}
+	now, err := ss.now()
+	if err != nil {
+		return nil, err
+	}
+	t := now.Add(s.composite.TTL)
+	return &t, nil
+}
+
+func (ss *simplestore) expired(w *Witness) (bool, error) {
+	if w.Expires == nil {
+		return false, nil
+	}
+	now, err := ss.now()
+	if err != nil {
+		return false, err
+	}
+	return !now.Before(*w.Expires), nil
+}
+
+// MaxSweepLimit es la cantidad máxima de instancias que recorre SweepComposite
+const MaxSweepLimit = 1000
+
+// SweepComposite purga las instancias expiradas de entre las siguientes limit
+func (ss *simplestore) SweepComposite(s *Schema, bookmark string, limit int) ([]interface{}, string, error) {
+	kbn := s.KeyBaseName()
+	if kbn == "" {
+		return nil, "", errors.Errorf("sweeping composite %q: keybasename is empty", s.Name())
+	}
+	if limit <= 0 {
+		return nil, "", errors.Errorf("sweeping composite %q: invalid limit %d", s.Name(), limit)
+	}
+	if limit > MaxSweepLimit {
+		limit = MaxSweepLimit
+	}
+	if !s.expires() {
+		return []interface{}{}, "", nil
+	}
+	first, last := key.NewBase(kbn, "").RangeUsing(ss.sep)
+	if bookmark != "" {
+		if bookmark < first || bookmark >= last {
+			return nil, "", errors.Errorf("sweeping composite %q: invalid bookmark %q", s.Name(), bookmark)
+		}
+		first = bookmark
+	}
+	states, err := ss.stub.GetStateByRange(first, last)
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "getting composite %q instances for sweeping", s.Name())
+	}
+	defer states.Close()
+	page := &pageIterator{states: states, sep: ss.sep, size: limit}
+	expired := []*key.Key{}
+	for page.HasNext() {
+		state, err := page.Next()
+		if err != nil {
+			return nil, "", errors.Wrapf(err, "getting composite %q iterator next key for sweeping", s.Name())
+		}
+		statekey, err := key.ParseUsing(state.GetKey(), ss.sep)
+		if err != nil {
+			return nil, "", errors.Wrapf(err, "parsing state key %q as composite %q key", state.GetKey(), s.Name())
+		}
+		if !s.IsWitnessKey(statekey) {
+			continue
+		}
+		w, err := ss.parseWitness(state.GetValue())
+		if err != nil {
+			return nil, "", errors.Wrapf(err, "parsing composite %q witness with key %q", s.Name(), state.GetKey())
+		}
+		if ok, err := ss.expired(w); err != nil {
+			return nil, "", errors.Wrapf(err, "checking composite %q witness with key %q", s.Name(), state.GetKey())
+		} else if ok {
+			expired = append(expired, key.NewBaseKey(statekey))
+		}
+	}
+	res := []interface{}{}
+	for _, valkey := range expired {
+		id, err := s.KeyIdentifier(valkey)
+		if err != nil {
+			return nil, "", errors.WithStack(err)
+		}
+		if err := ss.internalPurgeComposite(s, valkey); err != nil {
+			return nil, "", err
+		}
+		ss.recordChange(s, id, OperationExpire, "", nil)
+		res = append(res, id)
+	}
+	return res, page.next, nil
+}
@@ -0,0 +1,230 @@
+package store
+
+import (
+	"strings"
+
+	"github.com/hyperledger/fabric/protos/ledger/queryresult"
+	"github.com/pkg/errors"
+
+	"github.com/lalloni/fabrikit/chaincode/store/key"
+)
+
+// Repair indica qué corregir durante una verificación de integridad
+type Repair int
+
+const (
+	// RepairNone sólo reporta los problemas encontrados
+	RepairNone Repair = iota
+	// RepairRecreate recrea los testigos faltantes de instancias con miembros
+	RepairRecreate
+	// RepairDelete borra las claves de instancias sin testigo o sin miembros
+	RepairDelete
+)
+
+const (
+	ProblemUnparsableKey  = "unparsable-key"
+	ProblemInvalidKey     = "invalid-key"
+	ProblemInvalidValue   = "invalid-value"
+	ProblemUnknownMember  = "unknown-member"
+	ProblemMissingWitness = "missing-witness"
+	ProblemEmptyWitness   = "empty-witness"
+)
+
+type Problem struct {
+	Schema   string `json:"schema"`
+	Key      string `json:"key"`
+	Kind     string `json:"kind"`
+	Message  string `json:"message,omitempty"`
+	Repaired bool   `json:"repaired,omitempty"`
+}
+
+type IntegrityReport struct {
+	Scanned  int       `json:"scanned"`
+	Problems []Problem `json:"problems,omitempty"`
+	// Continuation es vacío cuando la verificación terminó
+	Continuation string `json:"continuation,omitempty"`
+}
+
+// MaxIntegrityLimit es la cantidad máxima de instancias que verifica CheckIntegrity
+const MaxIntegrityLimit = 1000
+
+// CheckIntegrity verifica hasta limit instancias de los schemas desde el token
+func (ss *simplestore) CheckIntegrity(schemas []*Schema, token string, limit int, repair Repair) (*IntegrityReport, error) {
+	if limit <= 0 || limit > MaxIntegrityLimit {
+		return nil, errors.Errorf("checking integrity: invalid limit %d", limit)
+	}
+	start, bookmark := 0, ""
+	if token != "" {
+		i := strings.Index(token, "|")
+		if i < 0 {
+			return nil, errors.Errorf("checking integrity: invalid continuation token %q", token)
+		}
+		name := token[:i]
+		start = -1
+		for n, s := range schemas {
+			if s.Name() == name {
+				start = n
+				break
+			}
+		}
+		if start < 0 {
+			return nil, errors.Errorf("checking integrity: unknown schema %q in continuation token", name)
+		}
+		bookmark = token[i+1:]
+	}
+	report := &IntegrityReport{}
+	for _, s := range schemas[start:] {
+		next, err := ss.checkSchemaIntegrity(s, bookmark, limit, repair, report)
+		if err != nil {
+			return nil, err
+		}
+		if next != "" {
+			report.Continuation = s.Name() + "|" + next
+			break
+		}
+		bookmark = ""
+	}
+	return report, nil
+}
+
+func (ss *simplestore) checkSchemaIntegrity(s *Schema, bookmark string, limit int, repair Repair, report *IntegrityReport) (string, error) {
+	kbn := s.KeyBaseName()
+	if kbn == "" {
+		return "", errors.Errorf("checking composite %q integrity: keybasename is empty", s.Name())
+	}
+	first, last := key.NewBase(kbn, "").RangeUsing(ss.sep)
+	if bookmark != "" {
+		if bookmark < first || bookmark >= last {
+			return "", errors.Errorf("checking composite %q integrity: invalid bookmark %q", s.Name(), bookmark)
+		}
+		first = bookmark
+	}
+	states, err := ss.stub.GetStateByRange(first, last)
+	if err != nil {
+		return "", errors.Wrapf(err, "getting composite %q states for checking", s.Name())
+	}
+	defer states.Close()
+	var (
+		base  string
+		group []*queryresult.KV
+	)
+	for states.HasNext() {
+		state, err := states.Next()
+		if err != nil {
+			return "", errors.Wrapf(err, "getting composite %q iterator next key for checking", s.Name())
+		}
+		statekey, err := key.ParseUsing(state.GetKey(), ss.sep)
+		if err != nil {
+			report.Problems = append(report.Problems, Problem{Schema: s.Name(), Key: state.GetKey(), Kind: ProblemUnparsableKey, Message: err.Error()})
+			continue
+		}
+		b := key.NewBaseKey(statekey).StringUsing(ss.sep)
+		if b != base {
+			if len(group) > 0 {
+				if err := ss.checkInstance(s, group, repair, report); err != nil {
+					return "", err
+				}
+				report.Scanned++
+			}
+			if report.Scanned == limit {
+				return b, nil
+			}
+			base, group = b, nil
+		}
+		group = append(group, state)
+	}
+	if len(group) > 0 {
+		if err := ss.checkInstance(s, group, repair, report); err != nil {
+			return "", err
+		}
+		report.Scanned++
+	}
+	return "", nil
+}
+
+// checkInstance verifica los estados de una instancia
+func (ss *simplestore) checkInstance(s *Schema, states []*queryresult.KV, repair Repair, report *IntegrityReport) error {
+	problem := func(k, kind, msg string) *Problem {
+		report.Problems = append(report.Problems, Problem{Schema: s.Name(), Key: k, Kind: kind, Message: msg})
+		return &report.Problems[len(report.Problems)-1]
+	}
+	var valkey *key.Key
+	witness, members := false, 0
+	for _, state := range states {
+		statekey, _ := key.ParseUsing(state.GetKey(), ss.sep)
+		valkey = key.NewBaseKey(statekey)
+		var val interface{}
+		switch tag := statekey.Tag.Name; {
+		case s.IsWitnessKey(statekey):
+			witness = true
+			if _, err := ss.parseWitness(state.GetValue()); err != nil {
+				problem(state.GetKey(), ProblemInvalidValue, err.Error())
+			}
+			continue
+		case s.IsAuditKey(statekey):
+			val = &Audit{}
+		case tag == "":
+			members++
+			v, err := s.Create()
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			val = v
+		case s.Singleton(tag) != nil:
+			members++
+			val = s.Singleton(tag).Creator()
+		case s.Collection(tag) != nil:
+			members++
+			val = s.Collection(tag).ItemCreator()
+		default:
+			problem(state.GetKey(), ProblemUnknownMember, "tag "+tag+" is not a member of the composite")
+			continue
+		}
+		if err := ss.internalParseValue(state.GetValue(), val); err != nil {
+			problem(state.GetKey(), ProblemInvalidValue, err.Error())
+		}
+	}
+	valkeys := valkey.StringUsing(ss.sep)
+	if _, err := s.KeyIdentifier(valkey); err != nil {
+		problem(valkeys, ProblemInvalidKey, err.Error())
+	}
+	switch {
+	case !witness && members > 0:
+		p := problem(valkeys, ProblemMissingWitness, "composite members have no witness")
+		switch repair {
+		case RepairRecreate:
+			if err := ss.internalPutValue(s.KeyWitness(valkey), witnessValue(nil)); err != nil {
+				return errors.Wrapf(err, "recreating composite %q witness with key %q", s.Name(), valkeys)
+			}
+			p.Repaired = true
+		case RepairDelete:
+			if err := ss.deleteStates(states); err != nil {
+				return errors.Wrapf(err, "deleting composite %q orphan members with key %q", s.Name(), valkeys)
+			}
+			p.Repaired = true
+		}
+	case witness && members == 0 && s.requiresMembers():
+		p := problem(valkeys, ProblemEmptyWitness, "composite witness has no members")
+		if repair == RepairDelete {
+			if err := ss.deleteStates(states); err != nil {
+				return errors.Wrapf(err, "deleting composite %q orphan witness with key %q", s.Name(), valkeys)
+			}
+			p.Repaired = true
+		}
+	}
+	return nil
+}
+
+// requiresMembers indica si toda instancia del composite tiene algún miembro
+func (cc *Schema) requiresMembers() bool {
+	return cc.composite.KeepRoot || len(cc.singletons) == 0 && len(cc.collections) == 0
+}
+
+func (ss *simplestore) deleteStates(states []*queryresult.KV) error {
+	for _, state := range states {
+		if err := ss.stub.DelState(state.GetKey()); err != nil {
+			return errors.Wrapf(err, "deleting state %q", state.GetKey())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package store
+
+import (
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/hyperledger/fabric/protos/ledger/queryresult"
+)
+
+// Metrics son los contadores de acceso al estado de la transacción actual
+type Metrics struct {
+	StatesRead         int `json:"states-read"`
+	RangeScans         int `json:"range-scans"`
+	KeysWritten        int `json:"keys-written"`
+	KeysDeleted        int `json:"keys-deleted"`
+	PlainBytesWritten  int `json:"plain-bytes-written"`
+	StoredBytesWritten int `json:"stored-bytes-written"`
+	StoredBytesRead    int `json:"stored-bytes-read"`
+	PlainBytesRead     int `json:"plain-bytes-read"`
+}
+
+func (ss *simplestore) Metrics() Metrics {
+	ss.checkTx()
+	return ss.metrics
+}
+
+func (ss *simplestore) meter() *Metrics {
+	ss.checkTx()
+	return &ss.metrics
+}
+
+// meteredStub cuenta los accesos al estado hechos por el store
+type meteredStub struct {
+	shim.ChaincodeStubInterface
+	ss *simplestore
+}
+
+func (s *meteredStub) GetState(k string) ([]byte, error) {
+	s.ss.meter().StatesRead++
+	return s.ChaincodeStubInterface.GetState(k)
+}
+
+func (s *meteredStub) PutState(k string, bs []byte) error {
+	s.ss.meter().KeysWritten++
+	return s.ChaincodeStubInterface.PutState(k, bs)
+}
+
+func (s *meteredStub) DelState(k string) error {
+	s.ss.meter().KeysDeleted++
+	return s.ChaincodeStubInterface.DelState(k)
+}
+
+func (s *meteredStub) GetStateByRange(first, last string) (shim.StateQueryIteratorInterface, error) {
+	s.ss.meter().RangeScans++
+	states, err := s.ChaincodeStubInterface.GetStateByRange(first, last)
+	if err != nil {
+		return nil, err
+	}
+	return &meteredIterator{StateQueryIteratorInterface: states, ss: s.ss}, nil
+}
+
+type meteredIterator struct {
+	shim.StateQueryIteratorInterface
+	ss *simplestore
+}
+
+func (i *meteredIterator) Next() (*queryresult.KV, error) {
+	kv, err := i.StateQueryIteratorInterface.Next()
+	if err == nil {
+		i.ss.meter().StatesRead++
+	}
+	return kv, err
+}
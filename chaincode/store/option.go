@@ -31,3 +31,15 @@ func SetErrors(b bool) Option {
 		s.seterrs = b
 	}
 }
+
+func SetAudit(b bool) Option {
+	return func(s *simplestore) {
+		s.getaudit = b
+	}
+}
+
+func SetDeleted(b bool) Option {
+	return func(s *simplestore) {
+		s.deleted = b
+	}
+}
@@ -8,8 +8,13 @@ import (
 	"github.com/pkg/errors"
 )
 
-func Options(stub shim.ChaincodeStubInterface) ([]Option, error) {
-	ss := strings.SplitN(string(stub.GetArgs()[0]), "?", 2)
+// Options devuelve las opciones del store en names especificadas en la función invocada
+func Options(stub shim.ChaincodeStubInterface, names ...string) ([]Option, error) {
+	args := stub.GetArgs()
+	if len(args) == 0 {
+		return nil, nil
+	}
+	ss := strings.SplitN(string(args[0]), "?", 2)
 	if len(ss) < 2 {
 		return nil, nil
 	}
@@ -18,8 +23,18 @@ func Options(stub shim.ChaincodeStubInterface) ([]Option, error) {
 		return nil, errors.Wrap(err, "parsing function options")
 	}
 	oo := []Option{}
-	if _, ok := q["embederrors"]; ok {
-		oo = append(oo, SetErrors(true))
+	for _, name := range names {
+		if _, ok := q[name]; !ok {
+			continue
+		}
+		switch name {
+		case "embederrors":
+			oo = append(oo, SetErrors(true))
+		case "audit":
+			oo = append(oo, SetAudit(true))
+		case "deleted":
+			oo = append(oo, SetDeleted(true))
+		}
 	}
 	return oo, nil
 }
@@ -0,0 +1,95 @@
+package store
+
+import (
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/hyperledger/fabric/protos/ledger/queryresult"
+	"github.com/pkg/errors"
+
+	"github.com/lalloni/fabrikit/chaincode/store/key"
+)
+
+// MaxPageSize es la cantidad máxima de instancias de una página
+const MaxPageSize = 1000
+
+// GetCompositePage devuelve hasta size instancias del composite a partir del bookmark
+func (ss *simplestore) GetCompositePage(s *Schema, bookmark string, size int) ([]interface{}, string, error) {
+	kbn := s.KeyBaseName()
+	if kbn == "" {
+		return nil, "", errors.Errorf("getting composite %q page: keybasename is empty", s.Name())
+	}
+	if size <= 0 || size > MaxPageSize {
+		return nil, "", errors.Errorf("getting composite %q page: invalid size %d", s.Name(), size)
+	}
+	first, last := key.NewBase(kbn, "").RangeUsing(ss.sep)
+	if bookmark != "" {
+		if bookmark < first || bookmark >= last {
+			return nil, "", errors.Errorf("getting composite %q page: invalid bookmark %q", s.Name(), bookmark)
+		}
+		first = bookmark
+	}
+	states, err := ss.stub.GetStateByRange(first, last)
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "getting composite %q page for reading", s.Name())
+	}
+	defer states.Close()
+	page := &pageIterator{states: states, sep: ss.sep, size: size}
+	vals, err := ss.internalReadCompositeIterator(s, page)
+	if err != nil {
+		return nil, "", err
+	}
+	return vals, page.next, nil
+}
+
+type pageIterator struct {
+	states shim.StateQueryIteratorInterface
+	sep    *key.Sep
+	size   int
+	count  int
+	base   string
+	peeked *queryresult.KV
+	next   string
+	err    error
+}
+
+func (p *pageIterator) HasNext() bool {
+	if p.peeked != nil || p.err != nil {
+		return true
+	}
+	if p.next != "" || !p.states.HasNext() {
+		return false
+	}
+	kv, err := p.states.Next()
+	if err != nil {
+		p.err = err
+		return true
+	}
+	k, err := key.ParseUsing(kv.GetKey(), p.sep)
+	if err != nil {
+		p.err = errors.Wrapf(err, "parsing state key %q", kv.GetKey())
+		return true
+	}
+	base := key.NewBaseKey(k).StringUsing(p.sep)
+	if base != p.base {
+		if p.count == p.size {
+			p.next = base
+			return false
+		}
+		p.count++
+		p.base = base
+	}
+	p.peeked = kv
+	return true
+}
+
+func (p *pageIterator) Next() (*queryresult.KV, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	kv := p.peeked
+	p.peeked = nil
+	return kv, nil
+}
+
+func (p *pageIterator) Close() error {
+	return p.states.Close()
+}
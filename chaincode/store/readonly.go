@@ -0,0 +1,104 @@
+package store
+
+import (
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/pkg/errors"
+
+	"github.com/lalloni/fabrikit/chaincode/store/key"
+)
+
+// ErrReadOnly es la causa de las escrituras fallidas en un store o stub de sólo lectura
+var ErrReadOnly = errors.New("store is read only")
+
+// ReadOnly devuelve un store que falla en todas las escrituras
+func ReadOnly(s Store) Store {
+	return &readonlystore{Store: s}
+}
+
+type readonlystore struct {
+	Store
+}
+
+func (rs *readonlystore) PutComposite(s *Schema, val interface{}) error {
+	return errors.Wrapf(ErrReadOnly, "putting composite %q", s.Name())
+}
+
+func (rs *readonlystore) DelComposite(s *Schema, id interface{}) error {
+	return errors.Wrapf(ErrReadOnly, "deleting composite %q", s.Name())
+}
+
+func (rs *readonlystore) RestoreComposite(s *Schema, id interface{}) (bool, error) {
+	return false, errors.Wrapf(ErrReadOnly, "restoring composite %q", s.Name())
+}
+
+func (rs *readonlystore) PurgeComposite(s *Schema, id interface{}) (bool, error) {
+	return false, errors.Wrapf(ErrReadOnly, "purging composite %q", s.Name())
+}
+
+func (rs *readonlystore) SweepComposite(s *Schema, bookmark string, limit int) ([]interface{}, string, error) {
+	return nil, "", errors.Wrapf(ErrReadOnly, "sweeping composite %q", s.Name())
+}
+
+func (rs *readonlystore) DelCompositeRange(s *Schema, r *Range) ([]interface{}, error) {
+	return nil, errors.Wrapf(ErrReadOnly, "deleting composite %q range", s.Name())
+}
+
+func (rs *readonlystore) PutCompositeSingleton(s *Singleton, id interface{}, val interface{}) error {
+	return errors.Wrapf(ErrReadOnly, "putting composite %q singleton %q", s.schema.Name(), s.Tag)
+}
+
+func (rs *readonlystore) PutCompositeCollection(c *Collection, id interface{}, col interface{}) error {
+	return errors.Wrapf(ErrReadOnly, "putting composite %q collection %q", c.schema.Name(), c.Tag)
+}
+
+func (rs *readonlystore) CheckIntegrity(schemas []*Schema, token string, limit int, repair Repair) (*IntegrityReport, error) {
+	if repair != RepairNone {
+		return nil, errors.Wrap(ErrReadOnly, "repairing integrity")
+	}
+	return rs.Store.CheckIntegrity(schemas, token, limit, repair)
+}
+
+func (rs *readonlystore) NextSequence(name string) (uint64, error) {
+	return 0, errors.Wrapf(ErrReadOnly, "incrementing sequence %q", name)
+}
+
+func (rs *readonlystore) PutValue(k *key.Key, val interface{}) error {
+	return errors.Wrapf(ErrReadOnly, "putting value with key %q", k)
+}
+
+func (rs *readonlystore) DelValue(k *key.Key) error {
+	return errors.Wrapf(ErrReadOnly, "deleting value with key %q", k)
+}
+
+// ReadOnlyStub devuelve un stub que falla en todas las escrituras
+func ReadOnlyStub(stub shim.ChaincodeStubInterface) shim.ChaincodeStubInterface {
+	return &readonlystub{ChaincodeStubInterface: stub}
+}
+
+type readonlystub struct {
+	shim.ChaincodeStubInterface
+}
+
+func (s *readonlystub) PutState(k string, bs []byte) error {
+	return errors.Wrapf(ErrReadOnly, "putting state %q", k)
+}
+
+func (s *readonlystub) DelState(k string) error {
+	return errors.Wrapf(ErrReadOnly, "deleting state %q", k)
+}
+
+func (s *readonlystub) SetStateValidationParameter(k string, ep []byte) error {
+	return errors.Wrapf(ErrReadOnly, "setting state %q validation parameter", k)
+}
+
+func (s *readonlystub) PutPrivateData(collection string, k string, bs []byte) error {
+	return errors.Wrapf(ErrReadOnly, "putting private data %q in collection %q", k, collection)
+}
+
+func (s *readonlystub) DelPrivateData(collection string, k string) error {
+	return errors.Wrapf(ErrReadOnly, "deleting private data %q in collection %q", k, collection)
+}
+
+func (s *readonlystub) SetPrivateDataValidationParameter(collection, k string, ep []byte) error {
+	return errors.Wrapf(ErrReadOnly, "setting private data %q in collection %q validation parameter", k, collection)
+}
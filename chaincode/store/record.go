@@ -0,0 +1,123 @@
+package store
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+
+	"github.com/lalloni/fabrikit/chaincode/store/key"
+)
+
+// Record es la representación sin pérdida de una instancia de composite
+type Record struct {
+	Key         string                                `json:"key"`
+	Root        json.RawMessage                       `json:"root,omitempty"`
+	Singletons  map[string]json.RawMessage            `json:"singletons,omitempty"`
+	Collections map[string]map[string]json.RawMessage `json:"collections,omitempty"`
+}
+
+func (cc *Schema) Record(val interface{}) (rec *Record, err error) {
+	valkey, err := cc.ValueKey(val)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		p := recover()
+		if p != nil {
+			err = errors.Errorf("building composite %q record: %v", cc.name, p)
+		}
+	}()
+	rec = &Record{Key: valkey.String()}
+	if rec.Root, err = json.Marshal(cc.Cleared(val)); err != nil {
+		return nil, errors.Wrapf(err, "marshaling composite %q root", cc.name)
+	}
+	for tag, singleton := range cc.singletons {
+		v := singleton.Getter(val)
+		if isNil(v) {
+			continue
+		}
+		bs, err := json.Marshal(v)
+		if err != nil {
+			return nil, errors.Wrapf(err, "marshaling composite %q singleton %q", cc.name, tag)
+		}
+		if rec.Singletons == nil {
+			rec.Singletons = map[string]json.RawMessage{}
+		}
+		rec.Singletons[tag] = bs
+	}
+	for tag, collection := range cc.collections {
+		col := collection.Getter(val)
+		if isNil(col) {
+			continue
+		}
+		items := map[string]json.RawMessage{}
+		for _, item := range collection.Enumerator(col) {
+			bs, err := json.Marshal(item.Value)
+			if err != nil {
+				return nil, errors.Wrapf(err, "marshaling composite %q collection %q item %q", cc.name, tag, item.Identifier)
+			}
+			items[item.Identifier] = bs
+		}
+		if rec.Collections == nil {
+			rec.Collections = map[string]map[string]json.RawMessage{}
+		}
+		rec.Collections[tag] = items
+	}
+	return rec, nil
+}
+
+func (cc *Schema) FromRecord(rec *Record) (val interface{}, err error) {
+	valkey, err := key.Parse(rec.Key)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing composite %q record key %q", cc.name, rec.Key)
+	}
+	id, err := cc.KeyIdentifier(valkey)
+	if err != nil {
+		return nil, err
+	}
+	val, err = cc.Create()
+	if err != nil {
+		return nil, err
+	}
+	if len(rec.Root) > 0 {
+		if err := json.Unmarshal(rec.Root, val); err != nil {
+			return nil, errors.Wrapf(err, "unmarshaling composite %q record root", cc.name)
+		}
+	}
+	if err := cc.SetIdentifier(val, id); err != nil {
+		return nil, err
+	}
+	defer func() {
+		p := recover()
+		if p != nil {
+			err = errors.Errorf("building composite %q value from record: %v", cc.name, p)
+		}
+	}()
+	for tag, bs := range rec.Singletons {
+		singleton := cc.singletons[tag]
+		if singleton == nil {
+			return nil, errors.Errorf("composite %q record has unknown singleton %q", cc.name, tag)
+		}
+		v := singleton.Creator()
+		if err := json.Unmarshal(bs, v); err != nil {
+			return nil, errors.Wrapf(err, "unmarshaling composite %q record singleton %q", cc.name, tag)
+		}
+		singleton.Setter(val, v)
+	}
+	for tag, items := range rec.Collections {
+		collection := cc.collections[tag]
+		if collection == nil {
+			return nil, errors.Errorf("composite %q record has unknown collection %q", cc.name, tag)
+		}
+		col := collection.Creator()
+		for itemid, bs := range items {
+			item := collection.ItemCreator()
+			if err := json.Unmarshal(bs, item); err != nil {
+				return nil, errors.Wrapf(err, "unmarshaling composite %q record collection %q item %q", cc.name, tag, itemid)
+			}
+			collection.Collector(col, Item{Identifier: itemid, Value: item})
+		}
+		collection.Setter(val, col)
+	}
+	return val, nil
+}
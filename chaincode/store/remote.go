@@ -0,0 +1,226 @@
+package store
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/pkg/errors"
+
+	"github.com/lalloni/fabrikit/chaincode/internal/invocation"
+	"github.com/lalloni/fabrikit/chaincode/store/key"
+)
+
+// Operaciones de lectura remota (ver RemoteFunction)
+const (
+	RemoteGet   = "Get"
+	RemoteHas   = "Has"
+	RemoteAll   = "All"
+	RemoteRange = "Range"
+	RemotePage  = "Page"
+)
+
+// RemoteFunction devuelve el nombre de la función de lectura remota op del composite
+func RemoteFunction(op string, s *Schema) string {
+	return "Remote" + op + strings.Title(s.Name())
+}
+
+// RemotePageResult es el contenido de la respuesta de RemotePage
+type RemotePageResult struct {
+	Records  []*Record `json:"records"`
+	Bookmark string    `json:"bookmark,omitempty"`
+}
+
+// Remote crea un store de sólo lectura que lee los composites de otro chaincode
+func Remote(stub shim.ChaincodeStubInterface, chaincode, channel string) Store {
+	return &remotestore{stub: stub, chaincode: chaincode, channel: channel}
+}
+
+type remotestore struct {
+	stub      shim.ChaincodeStubInterface
+	chaincode string
+	channel   string
+}
+
+func (rs *remotestore) invoke(fun string, content interface{}, args ...string) error {
+	inv := &invocation.Invocation{Chaincode: rs.chaincode, Channel: rs.channel, Function: fun}
+	for _, arg := range args {
+		inv.Args = append(inv.Args, arg)
+	}
+	return invocation.Invoke(rs.stub, log, inv, content, nil)
+}
+
+func (rs *remotestore) key(s *Schema, id interface{}) (string, error) {
+	k, err := s.IdentifierKey(id)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	return k.String(), nil
+}
+
+func (rs *remotestore) values(s *Schema, recs []*Record) ([]interface{}, error) {
+	res := []interface{}{}
+	for _, rec := range recs {
+		val, err := s.FromRecord(rec)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, val)
+	}
+	return res, nil
+}
+
+func (rs *remotestore) GetComposite(s *Schema, id interface{}) (interface{}, error) {
+	k, err := rs.key(s, id)
+	if err != nil {
+		return nil, err
+	}
+	var rec *Record
+	if err := rs.invoke(RemoteFunction(RemoteGet, s), &rec, k); err != nil {
+		return nil, errors.Wrapf(err, "getting remote composite %q with key %q", s.Name(), k)
+	}
+	if rec == nil {
+		return nil, nil
+	}
+	return s.FromRecord(rec)
+}
+
+func (rs *remotestore) HasComposite(s *Schema, id interface{}) (bool, error) {
+	k, err := rs.key(s, id)
+	if err != nil {
+		return false, err
+	}
+	has := false
+	if err := rs.invoke(RemoteFunction(RemoteHas, s), &has, k); err != nil {
+		return false, errors.Wrapf(err, "checking remote composite %q with key %q existence", s.Name(), k)
+	}
+	return has, nil
+}
+
+func (rs *remotestore) GetCompositeAll(s *Schema) ([]interface{}, error) {
+	recs := []*Record{}
+	if err := rs.invoke(RemoteFunction(RemoteAll, s), &recs); err != nil {
+		return nil, errors.Wrapf(err, "getting remote composite %q all instances", s.Name())
+	}
+	return rs.values(s, recs)
+}
+
+func (rs *remotestore) GetCompositePage(s *Schema, bookmark string, size int) ([]interface{}, string, error) {
+	page := &RemotePageResult{}
+	if err := rs.invoke(RemoteFunction(RemotePage, s), page, bookmark, strconv.Itoa(size)); err != nil {
+		return nil, "", errors.Wrapf(err, "getting remote composite %q page", s.Name())
+	}
+	vals, err := rs.values(s, page.Records)
+	if err != nil {
+		return nil, "", err
+	}
+	return vals, page.Bookmark, nil
+}
+
+func (rs *remotestore) GetCompositeRange(s *Schema, r *Range) ([]interface{}, error) {
+	first, err := rs.key(s, r.First)
+	if err != nil {
+		return nil, err
+	}
+	last, err := rs.key(s, r.Last)
+	if err != nil {
+		return nil, err
+	}
+	recs := []*Record{}
+	if err := rs.invoke(RemoteFunction(RemoteRange, s), &recs, first, last); err != nil {
+		return nil, errors.Wrapf(err, "getting remote composite %q range [%q,%q]", s.Name(), first, last)
+	}
+	return rs.values(s, recs)
+}
+
+func (rs *remotestore) GetCompositeSingleton(s *Singleton, id interface{}) (interface{}, error) {
+	val, err := rs.GetComposite(s.schema, id)
+	if err != nil || val == nil {
+		return nil, err
+	}
+	return s.Getter(val), nil
+}
+
+func (rs *remotestore) GetCompositeCollection(c *Collection, id interface{}) (interface{}, error) {
+	val, err := rs.GetComposite(c.schema, id)
+	if err != nil || val == nil {
+		return nil, err
+	}
+	return c.Getter(val), nil
+}
+
+func (rs *remotestore) GetCompositeAudit(s *Schema, id interface{}) (*Audit, error) {
+	return nil, errors.Errorf("getting remote composite %q audit: not supported", s.Name())
+}
+
+func (rs *remotestore) GetCompositePolicy(s *Schema, id interface{}) ([]byte, error) {
+	return nil, errors.Errorf("getting remote composite %q endorsement policy: not supported", s.Name())
+}
+
+func (rs *remotestore) CheckIntegrity(schemas []*Schema, token string, limit int, repair Repair) (*IntegrityReport, error) {
+	return nil, errors.New("checking remote integrity: not supported")
+}
+
+func (rs *remotestore) Changes() []Change {
+	return nil
+}
+
+func (rs *remotestore) Metrics() Metrics {
+	return Metrics{}
+}
+
+func (rs *remotestore) PutComposite(s *Schema, val interface{}) error {
+	return errors.Wrapf(ErrReadOnly, "putting remote composite %q", s.Name())
+}
+
+func (rs *remotestore) DelComposite(s *Schema, id interface{}) error {
+	return errors.Wrapf(ErrReadOnly, "deleting remote composite %q", s.Name())
+}
+
+func (rs *remotestore) RestoreComposite(s *Schema, id interface{}) (bool, error) {
+	return false, errors.Wrapf(ErrReadOnly, "restoring remote composite %q", s.Name())
+}
+
+func (rs *remotestore) PurgeComposite(s *Schema, id interface{}) (bool, error) {
+	return false, errors.Wrapf(ErrReadOnly, "purging remote composite %q", s.Name())
+}
+
+func (rs *remotestore) SweepComposite(s *Schema, bookmark string, limit int) ([]interface{}, string, error) {
+	return nil, "", errors.Wrapf(ErrReadOnly, "sweeping remote composite %q", s.Name())
+}
+
+func (rs *remotestore) DelCompositeRange(s *Schema, r *Range) ([]interface{}, error) {
+	return nil, errors.Wrapf(ErrReadOnly, "deleting remote composite %q range", s.Name())
+}
+
+func (rs *remotestore) PutCompositeSingleton(s *Singleton, id interface{}, val interface{}) error {
+	return errors.Wrapf(ErrReadOnly, "putting remote composite %q singleton %q", s.schema.Name(), s.Tag)
+}
+
+func (rs *remotestore) PutCompositeCollection(c *Collection, id interface{}, col interface{}) error {
+	return errors.Wrapf(ErrReadOnly, "putting remote composite %q collection %q", c.schema.Name(), c.Tag)
+}
+
+func (rs *remotestore) NextSequence(name string) (uint64, error) {
+	return 0, errors.Wrapf(ErrReadOnly, "incrementing remote sequence %q", name)
+}
+
+func (rs *remotestore) NextTxIdentifier() string {
+	return ""
+}
+
+func (rs *remotestore) PutValue(key *key.Key, val interface{}) error {
+	return errors.Wrapf(ErrReadOnly, "putting remote value with key %q", key)
+}
+
+func (rs *remotestore) GetValue(key *key.Key, val interface{}) (bool, error) {
+	return false, errors.Errorf("getting remote value with key %q: not supported", key)
+}
+
+func (rs *remotestore) HasValue(key *key.Key) (bool, error) {
+	return false, errors.Errorf("checking remote value with key %q existence: not supported", key)
+}
+
+func (rs *remotestore) DelValue(key *key.Key) error {
+	return errors.Wrapf(ErrReadOnly, "deleting remote value with key %q", key)
+}
@@ -9,7 +9,13 @@ import (
 	"github.com/lalloni/fabrikit/chaincode/store/key"
 )
 
-const witnessTag = "wit"
+const (
+	witnessTag  = "wit"
+	auditTag    = "aud"
+	sequenceTag = "seq"
+)
+
+var reservedTags = map[string]bool{witnessTag: true, auditTag: true, sequenceTag: true}
 
 func MustPrepare(com Composite) *Schema {
 	cc, err := Prepare(com)
@@ -59,9 +65,37 @@ func Prepare(com Composite) (*Schema, error) {
 			com.IdentifierSetter = FieldSetter(com.IdentifierField)
 		}
 	}
+	if com.IdentifierGenerator != nil && com.IdentifierSetter == nil {
+		return nil, errors.Errorf("composite %q identifier generator requires an identifier setter function or field name", com.Name)
+	}
 	if com.Copier == nil {
 		com.Copier = reflectionShallowCopy
 	}
+	if err := checkRules(com.Validations, value); err != nil {
+		return nil, errors.Wrapf(err, "composite %q validation rule", com.Name)
+	}
+	for _, singleton := range schema.singletons {
+		if err := checkRules(singleton.Validations, singleton.Creator()); err != nil {
+			return nil, errors.Wrapf(err, "composite %q singleton %q validation rule", com.Name, singleton.Tag)
+		}
+	}
+	for _, collection := range schema.collections {
+		if err := checkRules(collection.Validations, collection.ItemCreator()); err != nil {
+			return nil, errors.Wrapf(err, "composite %q collection %q validation rule", com.Name, collection.Tag)
+		}
+	}
+	if com.AuditField != "" {
+		if !com.Audit {
+			return nil, errors.Errorf("composite %q audit field %q requires audit to be enabled", com.Name, com.AuditField)
+		}
+		field, ok := valueType.FieldByName(com.AuditField)
+		if !ok {
+			return nil, errors.Errorf("composite %q audit field %q does not match any value field", com.Name, com.AuditField)
+		}
+		if field.Type != reflect.TypeOf(&Audit{}) {
+			return nil, errors.Errorf("composite %q audit field %q must be of type %s", com.Name, com.AuditField, reflect.TypeOf(&Audit{}))
+		}
+	}
 	return schema, nil
 }
 
@@ -69,7 +103,7 @@ func prepareCollection(collection *Collection, members map[string]interface{}, v
 	if collection.Tag == "" {
 		return errors.Errorf("composite collection %+v must specifify a tag name", collection)
 	}
-	if collection.Tag == witnessTag {
+	if reservedTags[collection.Tag] {
 		return errors.Errorf("reserved member tag: collection %+v", collection)
 	}
 	if _, ok := members[collection.Tag]; ok {
@@ -133,7 +167,7 @@ func prepareSingleton(singleton *Singleton, members map[string]interface{}, valu
 	if singleton.Tag == "" {
 		return errors.Errorf("composite singleton %+v must specifify a tag name", singleton)
 	}
-	if singleton.Tag == witnessTag {
+	if reservedTags[singleton.Tag] {
 		return errors.Errorf("reserved member tag: singleton %+v", singleton)
 	}
 	if _, ok := members[singleton.Tag]; ok {
@@ -331,6 +365,9 @@ func (cc *Schema) Cleared(v interface{}) interface{} {
 	for _, collection := range cc.collections {
 		collection.Clear(nv)
 	}
+	if cc.composite.AuditField != "" {
+		FieldClear(cc.composite.AuditField)(nv)
+	}
 	return nv
 }
 
@@ -349,6 +386,11 @@ func (cc *Schema) Create() (v interface{}, err error) {
 	return
 }
 
+// GeneratesIdentifier indica si el composite asigna identificadores a los valores nuevos
+func (cc *Schema) GeneratesIdentifier() bool {
+	return cc.composite.IdentifierGenerator != nil
+}
+
 func (cc *Schema) SetIdentifier(val, id interface{}) (err error) {
 	defer func() {
 		p := recover()
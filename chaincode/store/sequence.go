@@ -0,0 +1,78 @@
+package store
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/pkg/errors"
+
+	"github.com/lalloni/fabrikit/chaincode/store/key"
+)
+
+// GeneratorFunc genera el identificador de un nuevo valor de composite
+type GeneratorFunc func(st Store, s *Schema) (interface{}, error)
+
+// Sequence genera identificadores uint64 consecutivos guardados en el ledger
+func Sequence(name string) GeneratorFunc {
+	return func(st Store, s *Schema) (interface{}, error) {
+		return st.NextSequence(name)
+	}
+}
+
+// TxSequence genera identificadores string derivados del tx id
+func TxSequence() GeneratorFunc {
+	return func(st Store, s *Schema) (interface{}, error) {
+		return st.NextTxIdentifier(), nil
+	}
+}
+
+// SequenceKey devuelve la clave del contador de la secuencia
+func SequenceKey(name string) *key.Key {
+	return (&key.Key{}).Tagged(sequenceTag, name)
+}
+
+// NextSequence incrementa y devuelve el valor de la secuencia
+func (ss *simplestore) NextSequence(name string) (uint64, error) {
+	ss.checkTx()
+	n, ok := ss.seqs[name]
+	if !ok {
+		_, err := ss.internalGetValue(SequenceKey(name), &n)
+		if err != nil {
+			return 0, errors.Wrapf(err, "getting sequence %q value", name)
+		}
+	}
+	n++
+	if err := ss.internalPutValue(SequenceKey(name), n); err != nil {
+		return 0, errors.Wrapf(err, "putting sequence %q value", name)
+	}
+	if ss.seqs == nil {
+		ss.seqs = map[string]uint64{}
+	}
+	ss.seqs[name] = n
+	return n, nil
+}
+
+func (ss *simplestore) NextTxIdentifier() string {
+	ss.checkTx()
+	ss.txseq++
+	return fmt.Sprintf("%s.%d", ss.stub.GetTxID(), ss.txseq)
+}
+
+// generateIdentifier asigna un identificador generado al valor si no tiene uno
+func (ss *simplestore) generateIdentifier(s *Schema, val interface{}) error {
+	if s.composite.IdentifierGenerator == nil {
+		return nil
+	}
+	id, err := s.ValueIdentifier(val)
+	if err != nil {
+		return err
+	}
+	if id != nil && !reflect.ValueOf(id).IsZero() {
+		return nil
+	}
+	id, err = s.composite.IdentifierGenerator(ss, s)
+	if err != nil {
+		return errors.Wrapf(err, "generating composite %q id", s.name)
+	}
+	return s.SetIdentifier(val, id)
+}
@@ -2,6 +2,7 @@ package store
 
 import (
 	"reflect"
+	"time"
 
 	"github.com/hyperledger/fabric/core/chaincode/shim"
 	"github.com/hyperledger/fabric/protos/ledger/queryresult"
@@ -20,7 +21,12 @@ type Store interface {
 	HasComposite(s *Schema, id interface{}) (bool, error)
 	DelComposite(s *Schema, id interface{}) error
 
+	RestoreComposite(s *Schema, id interface{}) (bool, error)
+	PurgeComposite(s *Schema, id interface{}) (bool, error)
+	SweepComposite(s *Schema, bookmark string, limit int) ([]interface{}, string, error)
+
 	GetCompositeAll(s *Schema) ([]interface{}, error)
+	GetCompositePage(s *Schema, bookmark string, size int) ([]interface{}, string, error)
 
 	GetCompositeRange(s *Schema, r *Range) ([]interface{}, error)
 	DelCompositeRange(s *Schema, r *Range) ([]interface{}, error)
@@ -31,6 +37,26 @@ type Store interface {
 	PutCompositeCollection(c *Collection, id interface{}, col interface{}) error
 	GetCompositeCollection(c *Collection, id interface{}) (interface{}, error)
 
+	GetCompositeAudit(s *Schema, id interface{}) (*Audit, error)
+	GetCompositePolicy(s *Schema, id interface{}) ([]byte, error)
+
+	// integrity verification
+
+	CheckIntegrity(schemas []*Schema, token string, limit int, repair Repair) (*IntegrityReport, error)
+
+	// identifier generation
+
+	NextSequence(name string) (uint64, error)
+	NextTxIdentifier() string
+
+	// changes recorded for composites with events enabled
+
+	Changes() []Change
+
+	// state access counters of the current transaction
+
+	Metrics() Metrics
+
 	// low level k/v access methods
 
 	PutValue(key *key.Key, val interface{}) error
@@ -47,6 +73,7 @@ func New(stub shim.ChaincodeStubInterface, opts ...Option) Store {
 		sep:        key.DefaultSep,
 		log:        shim.NewLogger("store"),
 	}
+	s.stub = &meteredStub{ChaincodeStubInterface: stub, ss: s}
 	for _, opt := range opts {
 		opt(s)
 	}
@@ -60,6 +87,15 @@ type simplestore struct {
 	filtering  filtering.Filtering
 	sep        *key.Sep
 	seterrs    bool
+	getaudit   bool
+	deleted    bool
+	changes    []Change
+	txid       string
+	audit      *AuditEntry
+	txtime     *time.Time
+	txseq      int
+	seqs       map[string]uint64
+	metrics    Metrics
 }
 
 func (ss *simplestore) PutValue(k *key.Key, value interface{}) error {
@@ -79,14 +115,36 @@ func (ss *simplestore) DelValue(k *key.Key) error {
 }
 
 func (ss *simplestore) PutComposite(s *Schema, val interface{}) error {
+	if err := ss.generateIdentifier(s, val); err != nil {
+		return err
+	}
+	if err := s.Validate(val); err != nil {
+		return err
+	}
+	var policy []byte
+	if s.endorsed() {
+		p, err := s.Policy(val)
+		if err != nil {
+			return err
+		}
+		policy = p
+	}
 	we, err := s.ValueWitness(val)
 	if err != nil {
 		return errors.Wrapf(err, "getting composite %q value witness", s.name)
 	}
-	err = ss.ensureCompositeWitness(s, we)
+	if s.expires() {
+		exp, err := ss.expiry(s, val)
+		if err != nil {
+			return errors.Wrapf(err, "getting composite %q value expiry", s.name)
+		}
+		we.Value = witnessValue(&Witness{Expires: exp})
+	}
+	err = ss.ensureCompositeWitness(s, we, true)
 	if err != nil {
 		return errors.Wrapf(err, "ensuring composite %q value witness", s.name)
 	}
+	written := []*key.Key{we.Key}
 	hascomps := false
 	entries, err := s.SingletonsEntries(val)
 	if err != nil {
@@ -100,6 +158,7 @@ func (ss *simplestore) PutComposite(s *Schema, val interface{}) error {
 			if err := ss.internalPutValue(entry.Key, entry.Value); err != nil {
 				return errors.Wrapf(err, "putting composite %q singleton %q", s.Name(), entry)
 			}
+			written = append(written, entry.Key)
 		}
 	}
 	entries, err = s.CollectionsEntries(val)
@@ -113,6 +172,7 @@ func (ss *simplestore) PutComposite(s *Schema, val interface{}) error {
 	if err != nil {
 		return errors.WithStack(err)
 	}
+	written = append(written, putEntriesKeys(entries)...)
 	if !hascomps || s.MustKeepRoot(val) {
 		entry, err := s.RootEntry(val)
 		if err != nil {
@@ -121,7 +181,28 @@ func (ss *simplestore) PutComposite(s *Schema, val interface{}) error {
 		if err := ss.internalPutValue(entry.Key, entry.Value); err != nil {
 			return errors.Wrapf(err, "putting composite %q root entry %q", s.Name(), entry)
 		}
+		written = append(written, entry.Key)
+	}
+	valkey, err := s.ValueKey(val)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if err := ss.touchAudit(s, valkey); err != nil {
+		return errors.WithStack(err)
 	}
+	if s.endorsed() {
+		if s.composite.Audit {
+			written = append(written, s.AuditKey(valkey))
+		}
+		if err := ss.endorse(s, policy, written); err != nil {
+			return err
+		}
+	}
+	id, err := s.ValueIdentifier(val)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	ss.recordChange(s, id, OperationPut, "", val)
 	return nil
 }
 
@@ -175,12 +256,14 @@ func (ss *simplestore) HasComposite(s *Schema, id interface{}) (bool, error) {
 		return false, errors.WithStack(err)
 	}
 	wk := s.KeyWitness(key)
-	var a interface{}
-	found, err := ss.internalGetValue(wk, &a)
+	w, found, err := ss.getWitness(wk)
 	if err != nil {
 		return false, errors.Wrapf(err, "getting composite %q witness with key %q", s.Name(), wk.StringUsing(ss.sep))
 	}
-	return found, nil
+	if !found {
+		return false, nil
+	}
+	return ss.visible(w)
 }
 
 func (ss *simplestore) DelComposite(s *Schema, id interface{}) error {
@@ -188,21 +271,14 @@ func (ss *simplestore) DelComposite(s *Schema, id interface{}) error {
 	if err != nil {
 		return errors.WithStack(err)
 	}
-	states, err := ss.stub.GetStateByRange(key.RangeUsing(ss.sep))
-	if err != nil {
-		return errors.Wrapf(err, "getting composite %q states with key %q for deletion", s.Name(), key)
+	if s.composite.SoftDelete {
+		_, err := ss.softDeleteComposite(s, id, key)
+		return err
 	}
-	defer states.Close()
-	for states.HasNext() {
-		state, err := states.Next()
-		if err != nil {
-			return errors.Wrapf(err, "getting composite %q with key %q next state for deletion", s.Name(), key)
-		}
-		err = ss.stub.DelState(state.GetKey())
-		if err != nil {
-			return errors.Wrapf(err, "deleting composite %q with key %q state %q", s.Name(), key, state.GetKey())
-		}
+	if err := ss.internalPurgeComposite(s, key); err != nil {
+		return err
 	}
+	ss.recordChange(s, id, OperationDelete, "", nil)
 	return nil
 }
 
@@ -231,7 +307,24 @@ func (ss *simplestore) DelCompositeRange(s *Schema, r *Range) ([]interface{}, er
 			if err != nil {
 				return nil, errors.WithStack(err)
 			}
+			if s.composite.SoftDelete {
+				w, err := ss.parseWitness(state.GetValue())
+				if err != nil {
+					return nil, errors.Wrapf(err, "parsing composite %q witness with key %q", s.Name(), state.GetKey())
+				}
+				if w.Deleted == nil {
+					if err := ss.tombstone(s, id, statekey, w); err != nil {
+						return nil, err
+					}
+					res = append(res, id)
+				}
+				continue
+			}
 			res = append(res, id)
+			ss.recordChange(s, id, OperationDelete, "", nil)
+		}
+		if s.composite.SoftDelete {
+			continue
 		}
 		err = ss.stub.DelState(state.GetKey())
 		if err != nil {
@@ -269,11 +362,14 @@ func (ss *simplestore) GetCompositeAll(s *Schema) ([]interface{}, error) {
 }
 
 func (ss *simplestore) PutCompositeSingleton(s *Singleton, id interface{}, val interface{}) error {
+	if err := s.Validate(val); err != nil {
+		return err
+	}
 	we, err := s.schema.IdentifierWitness(id)
 	if err != nil {
 		return errors.Wrapf(err, "getting composite %q value witness for id %q", s.schema.name, id)
 	}
-	err = ss.ensureCompositeWitness(s.schema, we)
+	err = ss.ensureCompositeWitness(s.schema, we, false)
 	if err != nil {
 		return errors.Wrapf(err, "ensuring composite %q value witness for id %q", s.schema.name, id)
 	}
@@ -281,11 +377,18 @@ func (ss *simplestore) PutCompositeSingleton(s *Singleton, id interface{}, val i
 	if err != nil {
 		return errors.Wrapf(err, "calculating composite %q with id %v key", s.schema.name, id)
 	}
-	key := valkey.Tagged(s.Tag)
-	err = ss.internalPutValue(key, val)
+	skey := valkey.Tagged(s.Tag)
+	err = ss.internalPutValue(skey, val)
 	if err != nil {
-		return errors.Wrapf(err, "putting composite %q with key %q singleton %q value", s.schema.name, valkey, key)
+		return errors.Wrapf(err, "putting composite %q with key %q singleton %q value", s.schema.name, valkey, skey)
 	}
+	if err := ss.touchAudit(s.schema, valkey); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := ss.inheritPolicy(s.schema, id, []*key.Key{skey}); err != nil {
+		return err
+	}
+	ss.recordChange(s.schema, id, OperationPatch, s.Tag, val)
 	return nil
 }
 
@@ -294,6 +397,11 @@ func (ss *simplestore) GetCompositeSingleton(s *Singleton, id interface{}) (inte
 	if err != nil {
 		return nil, errors.Wrapf(err, "calculating composite %q with id %v key", s.schema.name, id)
 	}
+	if s.schema.tracksWitness() {
+		if ok, err := ss.HasComposite(s.schema, id); err != nil || !ok {
+			return nil, err
+		}
+	}
 	skey := valkey.Tagged(s.Tag)
 	sval := s.Creator()
 	ok, err := ss.internalGetValue(skey, sval)
@@ -307,15 +415,35 @@ func (ss *simplestore) GetCompositeSingleton(s *Singleton, id interface{}) (inte
 }
 
 func (ss *simplestore) PutCompositeCollection(c *Collection, id interface{}, col interface{}) error {
+	if err := c.Validate(col); err != nil {
+		return err
+	}
 	valkey, err := c.schema.IdentifierKey(id)
 	if err != nil {
 		return errors.Wrapf(err, "calculating composite %q with id %v key", c.schema.name, id)
 	}
+	if c.schema.tracksWitness() {
+		we, err := c.schema.IdentifierWitness(id)
+		if err != nil {
+			return errors.Wrapf(err, "getting composite %q value witness for id %q", c.schema.name, id)
+		}
+		err = ss.ensureCompositeWitness(c.schema, we, false)
+		if err != nil {
+			return errors.Wrapf(err, "ensuring composite %q value witness for id %q", c.schema.name, id)
+		}
+	}
 	entries := c.schema.CollectionEntries(c, valkey, col)
 	err = ss.internalPutCollectionsEntries(c.schema, entries)
 	if err != nil {
 		return errors.WithStack(err)
 	}
+	if err := ss.touchAudit(c.schema, valkey); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := ss.inheritPolicy(c.schema, id, putEntriesKeys(entries)); err != nil {
+		return err
+	}
+	ss.recordChange(c.schema, id, OperationPatch, c.Tag, col)
 	return nil
 }
 
@@ -324,6 +452,11 @@ func (ss *simplestore) GetCompositeCollection(c *Collection, id interface{}) (in
 	if err != nil {
 		return nil, errors.Wrapf(err, "calculating composite %q with id %v key", c.schema.name, id)
 	}
+	if c.schema.tracksWitness() {
+		if ok, err := ss.HasComposite(c.schema, id); err != nil || !ok {
+			return nil, err
+		}
+	}
 	basekey := valkey.Tagged(c.Tag)
 	first, last := basekey.RangeUsing(ss.sep)
 	states, err := ss.stub.GetStateByRange(first, last)
@@ -364,15 +497,43 @@ func (ss *simplestore) internalPutCollectionsEntries(s *Schema, entries []*Entry
 	return nil
 }
 
-func (ss *simplestore) ensureCompositeWitness(s *Schema, we *Entry) error {
-	exist, err := ss.internalHasValue(we.Key)
+func putEntriesKeys(entries []*Entry) []*key.Key {
+	keys := []*key.Key{}
+	for _, entry := range entries {
+		if !reflect.ValueOf(entry.Value).IsNil() {
+			keys = append(keys, entry.Key)
+		}
+	}
+	return keys
+}
+
+// ensureCompositeWitness crea o actualiza el testigo del composite
+func (ss *simplestore) ensureCompositeWitness(s *Schema, we *Entry, full bool) error {
+	w, exist, err := ss.getWitness(we.Key)
 	if err != nil {
 		return errors.Wrapf(err, "checking composite %q witness existence", s.Name())
 	}
-	if !exist {
-		if err := ss.internalPutValue(we.Key, we.Value); err != nil {
-			return errors.Wrapf(err, "putting composite %q witness", s.Name())
+	live := false
+	if exist {
+		expired, err := ss.expired(w)
+		if err != nil {
+			return errors.Wrapf(err, "checking composite %q expiry", s.Name())
+		}
+		live = w.Deleted == nil && !expired
+	}
+	if live && !(full && s.expires()) {
+		return nil
+	}
+	if exist && !live {
+		if !full {
+			return errors.Errorf("composite %q with key %q is deleted or expired", s.Name(), key.NewBaseKey(we.Key))
 		}
+		if err := ss.internalPurgeComposite(s, key.NewBaseKey(we.Key)); err != nil {
+			return errors.Wrapf(err, "purging deleted composite %q", s.Name())
+		}
+	}
+	if err := ss.internalPutValue(we.Key, we.Value); err != nil {
+		return errors.Wrapf(err, "putting composite %q witness", s.Name())
 	}
 	return nil
 }
@@ -384,6 +545,7 @@ func (ss *simplestore) internalReadCompositeIterator(s *Schema, states shim.Stat
 	)
 	merrs := []MemberError{}
 	res := []interface{}{}
+	hidden := map[int]bool{}
 	for states.HasNext() {
 		state, err := states.Next()
 		if err != nil {
@@ -414,11 +576,34 @@ func (ss *simplestore) internalReadCompositeIterator(s *Schema, states shim.Stat
 			}
 			res = append(res, val)
 		}
+		if s.tracksWitness() && s.IsWitnessKey(statekey) {
+			w, err := ss.parseWitness(state.GetValue())
+			if err != nil {
+				return nil, errors.Wrapf(err, "parsing composite %q witness with key %q", s.Name(), state.GetKey())
+			}
+			visible, err := ss.visible(w)
+			if err != nil {
+				return nil, errors.Wrapf(err, "checking composite %q witness with key %q", s.Name(), state.GetKey())
+			}
+			if !visible {
+				hidden[len(res)-1] = true
+			}
+			continue
+		}
 		merr := ss.inject(s, statekey, state, valkey, val)
 		if ss.seterrs && merr != nil {
 			merrs = append(merrs, *merr)
 		}
 	}
+	if len(hidden) > 0 {
+		visible := []interface{}{}
+		for i, v := range res {
+			if !hidden[i] {
+				visible = append(visible, v)
+			}
+		}
+		res = visible
+	}
 	return res, nil
 }
 
@@ -427,14 +612,17 @@ func (ss *simplestore) internalPutValue(k *key.Key, value interface{}) error {
 		return errors.Wrap(err, "checking value key")
 	} else if bs, err := ss.marshaling.Marshal(value); err != nil {
 		return errors.Wrap(err, "marshaling value")
-	} else if bs, err := ss.filtering.Filter(bs); err != nil {
+	} else if fbs, err := ss.filtering.Filter(bs); err != nil {
 		return errors.Wrap(err, "filtering value")
 	} else {
+		m := ss.meter()
+		m.PlainBytesWritten += len(bs)
+		m.StoredBytesWritten += len(fbs)
 		ks := k.StringUsing(ss.sep)
 		if log.IsEnabledFor(shim.LogDebug) {
-			log.Debugf("putting key '%s' with value '%s'", ks, string(bs))
+			log.Debugf("putting key '%s' with value '%s'", ks, string(fbs))
 		}
-		if err := ss.stub.PutState(ks, bs); err != nil {
+		if err := ss.stub.PutState(ks, fbs); err != nil {
 			return errors.Wrap(err, "putting marshaled value into state")
 		}
 	}
@@ -469,9 +657,14 @@ func (ss *simplestore) internalDelValue(k *key.Key) error {
 }
 
 func (ss *simplestore) internalParseValue(bs []byte, value interface{}) error {
-	if bs, err := ss.filtering.Unfilter(bs); err != nil {
+	m := ss.meter()
+	m.StoredBytesRead += len(bs)
+	bs, err := ss.filtering.Unfilter(bs)
+	if err != nil {
 		return errors.Wrap(err, "unfiltering value")
-	} else if err := ss.marshaling.Unmarshal(bs, value); err != nil {
+	}
+	m.PlainBytesRead += len(bs)
+	if err := ss.marshaling.Unmarshal(bs, value); err != nil {
 		return errors.Wrap(err, "unmarshaling value")
 	}
 	return nil
@@ -530,6 +723,21 @@ func (ss *simplestore) inject(s *Schema, statekey *key.Key, state *queryresult.K
 			}
 		}
 		member.Setter(val, itemval)
+	case s.IsAuditKey(statekey):
+		if !ss.getaudit || s.composite.AuditField == "" {
+			break
+		}
+		audit := &Audit{}
+		err := ss.internalParseValue(state.GetValue(), audit)
+		if err != nil {
+			ss.log.Errorf("parsing composite %q with key %q audit value in tx %s: %v", s.Name(), valkey, ss.stub.GetTxID(), err)
+			merr = &MemberError{
+				Kind:  "audit",
+				Error: err.Error(),
+			}
+			break
+		}
+		FieldSetter(s.composite.AuditField)(val, audit)
 	}
 	return merr
 }
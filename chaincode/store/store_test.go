@@ -6,13 +6,17 @@ import (
 	"encoding/json"
 	"strconv"
 	"testing"
+	"time"
 
+	"github.com/golang/protobuf/ptypes"
 	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/hyperledger/fabric/core/chaincode/shim/ext/statebased"
 	"github.com/op/go-logging"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/lalloni/fabrikit/chaincode/store"
 	"github.com/lalloni/fabrikit/chaincode/store/key"
+	"github.com/lalloni/fabrikit/chaincode/storeutil"
 )
 
 type Thingy struct {
@@ -566,3 +570,491 @@ func deepCopy(src, tgt interface{}) error {
 	}
 	return gob.NewDecoder(bytes.NewReader(b.Bytes())).Decode(tgt)
 }
+
+func TestTypedSchema(t *testing.T) {
+	a := assert.New(t)
+
+	shim.SetLoggingLevel(shim.LogDebug)
+	logging.SetLevel(logging.DEBUG, "mock")
+
+	stub := shim.NewMockStub("test", nil)
+	st := store.New(stub)
+
+	tc, err := store.Typed[Compo](cc)
+	a.NoError(err)
+
+	_, err = store.Typed[Thing](cc)
+	a.Error(err)
+
+	c1 := &Compo{
+		Thing: &Thing{1234, "PP", 16, []Thingy{{"A"}, {"B"}}, ""},
+		Items: map[string]*Item{"a": {Name: "Pedro", Quantity: 10.0}},
+		Foos:  map[string]*Foo{},
+	}
+
+	stub.MockTransactionStart("x")
+	err = tc.Put(st, c1)
+	stub.MockTransactionEnd("x")
+	a.NoError(err)
+
+	c2, err := tc.Get(st, c1.Thing.ID)
+	a.NoError(err)
+	a.EqualValues(c1, c2)
+
+	c3, err := tc.Get(st, uint64(1))
+	a.NoError(err)
+	a.Nil(c3)
+
+	cs, err := tc.Range(st, store.R(uint64(1), uint64(2000)))
+	a.NoError(err)
+	a.Len(cs, 1)
+	a.EqualValues(c1, cs[0])
+}
+
+type Audited struct {
+	ID    uint64       `json:"id,omitempty"`
+	Name  string       `json:"name,omitempty"`
+	Audit *store.Audit `json:"audit,omitempty"`
+}
+
+var acc = store.MustPrepare(store.Composite{
+	Name:            "audited",
+	Creator:         func() interface{} { return &Audited{} },
+	KeyBaseName:     "audited",
+	IdentifierField: "ID",
+	IdentifierKey: func(id interface{}) (*key.Key, error) {
+		return key.NewBase("audited", strconv.FormatUint(id.(uint64), 10)), nil
+	},
+	KeyIdentifier: func(k *key.Key) (interface{}, error) {
+		return strconv.ParseUint(k.Base[0].Value, 10, 64)
+	},
+	Audit:      true,
+	AuditField: "Audit",
+})
+
+func TestAudit(t *testing.T) {
+	a := assert.New(t)
+
+	stub := shim.NewMockStub("test", nil)
+
+	forged := &store.Audit{Created: &store.AuditEntry{TxID: "forged"}}
+
+	stub.MockTransactionStart("x1")
+	err := store.New(stub).PutComposite(acc, &Audited{ID: 1, Name: "pepe", Audit: forged})
+	stub.MockTransactionEnd("x1")
+	a.NoError(err)
+
+	stub.MockTransactionStart("x2")
+	err = store.New(stub).PutComposite(acc, &Audited{ID: 1, Name: "pepa"})
+	stub.MockTransactionEnd("x2")
+	a.NoError(err)
+
+	st := store.New(stub)
+	audit, err := st.GetCompositeAudit(acc, uint64(1))
+	a.NoError(err)
+	a.NotNil(audit)
+	a.EqualValues("x1", audit.Created.TxID)
+	a.EqualValues("x2", audit.Modified.TxID)
+	a.False(audit.Modified.Time.IsZero())
+
+	v, err := st.GetComposite(acc, uint64(1))
+	a.NoError(err)
+	a.Nil(v.(*Audited).Audit)
+
+	v, err = store.New(stub, store.SetAudit(true)).GetComposite(acc, uint64(1))
+	a.NoError(err)
+	a.EqualValues(audit, v.(*Audited).Audit)
+	a.EqualValues("pepa", v.(*Audited).Name)
+}
+
+var scc = store.MustPrepare(store.Composite{
+	Name:            "soft",
+	Creator:         func() interface{} { return &Audited{} },
+	KeyBaseName:     "soft",
+	IdentifierField: "ID",
+	IdentifierKey: func(id interface{}) (*key.Key, error) {
+		return key.NewBase("soft", strconv.FormatUint(id.(uint64), 10)), nil
+	},
+	KeyIdentifier: func(k *key.Key) (interface{}, error) {
+		return strconv.ParseUint(k.Base[0].Value, 10, 64)
+	},
+	SoftDelete: true,
+})
+
+func TestSoftDelete(t *testing.T) {
+	a := assert.New(t)
+
+	stub := shim.NewMockStub("test", nil)
+	st := store.New(stub)
+
+	for id := uint64(1); id <= 5; id++ {
+		stub.MockTransactionStart("put")
+		err := st.PutComposite(scc, &Audited{ID: id, Name: "pepe"})
+		stub.MockTransactionEnd("put")
+		a.NoError(err)
+	}
+
+	stub.MockTransactionStart("del")
+	err := st.DelComposite(scc, uint64(1))
+	a.NoError(err)
+	ids, err := st.DelCompositeRange(scc, store.R(uint64(3), uint64(4)))
+	a.NoError(err)
+	a.EqualValues([]interface{}{uint64(3), uint64(4)}, ids)
+	stub.MockTransactionEnd("del")
+
+	has, err := st.HasComposite(scc, uint64(1))
+	a.NoError(err)
+	a.False(has)
+	v, err := st.GetComposite(scc, uint64(1))
+	a.NoError(err)
+	a.Nil(v)
+	vs, err := st.GetCompositeAll(scc)
+	a.NoError(err)
+	a.Len(vs, 2)
+
+	all := store.New(stub, store.SetDeleted(true))
+	vs, err = all.GetCompositeAll(scc)
+	a.NoError(err)
+	a.Len(vs, 5)
+	v, err = all.GetComposite(scc, uint64(1))
+	a.NoError(err)
+	a.EqualValues(&Audited{ID: 1, Name: "pepe"}, v)
+
+	stub.MockTransactionStart("restore")
+	ok, err := st.RestoreComposite(scc, uint64(1))
+	a.NoError(err)
+	a.True(ok)
+	ok, err = st.RestoreComposite(scc, uint64(2))
+	a.NoError(err)
+	a.False(ok)
+	ok, err = st.PurgeComposite(scc, uint64(3))
+	a.NoError(err)
+	a.True(ok)
+	stub.MockTransactionEnd("restore")
+
+	v, err = st.GetComposite(scc, uint64(1))
+	a.NoError(err)
+	a.EqualValues(&Audited{ID: 1, Name: "pepe"}, v)
+	v, err = all.GetComposite(scc, uint64(3))
+	a.NoError(err)
+	a.Nil(v)
+	vs, err = all.GetCompositeAll(scc)
+	a.NoError(err)
+	a.Len(vs, 4)
+}
+
+var tcc = store.MustPrepare(store.Composite{
+	Name:            "ttl",
+	Creator:         func() interface{} { return &Audited{} },
+	KeyBaseName:     "ttl",
+	IdentifierField: "ID",
+	IdentifierKey: func(id interface{}) (*key.Key, error) {
+		return key.NewBase("ttl", strconv.FormatUint(id.(uint64), 10)), nil
+	},
+	KeyIdentifier: func(k *key.Key) (interface{}, error) {
+		return strconv.ParseUint(k.Base[0].Value, 10, 64)
+	},
+	TTL: time.Hour,
+})
+
+func TestExpiry(t *testing.T) {
+	a := assert.New(t)
+
+	stub := shim.NewMockStub("test", nil)
+	st := store.New(stub)
+	t0 := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	at := func(tx string, d time.Duration) {
+		stub.MockTransactionStart(tx)
+		ts, err := ptypes.TimestampProto(t0.Add(d))
+		a.NoError(err)
+		stub.TxTimestamp = ts
+	}
+
+	for id := uint64(1); id <= 3; id++ {
+		at("put"+strconv.FormatUint(id, 10), time.Duration(id)*time.Minute)
+		a.NoError(st.PutComposite(tcc, &Audited{ID: id, Name: "pepe"}))
+		stub.MockTransactionEnd("put")
+	}
+
+	at("read1", 30*time.Minute)
+	vs, err := st.GetCompositeAll(tcc)
+	a.NoError(err)
+	a.Len(vs, 3)
+	stub.MockTransactionEnd("read1")
+
+	at("read2", 62*time.Minute)
+	has, err := st.HasComposite(tcc, uint64(1))
+	a.NoError(err)
+	a.False(has)
+	vs, err = st.GetCompositeAll(tcc)
+	a.NoError(err)
+	a.Len(vs, 1)
+	v, err := st.GetComposite(tcc, uint64(3))
+	a.NoError(err)
+	a.EqualValues(&Audited{ID: 3, Name: "pepe"}, v)
+	stub.MockTransactionEnd("read2")
+
+	at("sweep", 62*time.Minute)
+	_, _, err = st.SweepComposite(tcc, "", 0)
+	a.Error(err)
+	ids, bookmark, err := st.SweepComposite(tcc, "", 1)
+	a.NoError(err)
+	a.EqualValues([]interface{}{uint64(1)}, ids)
+	a.NotEmpty(bookmark)
+	ids, bookmark, err = st.SweepComposite(tcc, bookmark, 10)
+	a.NoError(err)
+	a.EqualValues([]interface{}{uint64(2)}, ids)
+	a.Empty(bookmark)
+	stub.MockTransactionEnd("sweep")
+
+	a.Nil(stub.State["ttl:1#wit"])
+	a.NotNil(stub.State["ttl:3#wit"])
+}
+
+func TestIdentifierGenerators(t *testing.T) {
+	a := assert.New(t)
+
+	seq := store.MustPrepare(store.Composite{
+		Name:                "seq",
+		Creator:             func() interface{} { return &Audited{} },
+		KeyBaseName:         "seq",
+		IdentifierField:     "ID",
+		IdentifierGenerator: store.Sequence("seq"),
+		IdentifierKey: func(id interface{}) (*key.Key, error) {
+			return key.NewBase("seq", strconv.FormatUint(id.(uint64), 10)), nil
+		},
+		KeyIdentifier: func(k *key.Key) (interface{}, error) {
+			return strconv.ParseUint(k.Base[0].Value, 10, 64)
+		},
+	})
+
+	stub := shim.NewMockStub("test", nil)
+	st := store.New(stub)
+
+	stub.MockTransactionStart("tx1")
+	v1, v2 := &Audited{Name: "a"}, &Audited{Name: "b"}
+	a.NoError(st.PutComposite(seq, v1))
+	a.NoError(st.PutComposite(seq, v2))
+	a.EqualValues(1, v1.ID)
+	a.EqualValues(2, v2.ID)
+	a.NoError(st.PutComposite(seq, &Audited{ID: 10, Name: "c"}))
+	stub.MockTransactionEnd("tx1")
+
+	stub.MockTransactionStart("tx2")
+	v3 := &Audited{Name: "d"}
+	a.NoError(st.PutComposite(seq, v3))
+	a.EqualValues(3, v3.ID)
+	stub.MockTransactionEnd("tx2")
+
+	vs, err := st.GetCompositeAll(seq)
+	a.NoError(err)
+	a.Len(vs, 4)
+
+	type Doc struct {
+		ID   string
+		Name string
+	}
+	txs := store.MustPrepare(store.Composite{
+		Name:                "txs",
+		Creator:             func() interface{} { return &Doc{} },
+		KeyBaseName:         "txs",
+		IdentifierField:     "ID",
+		IdentifierGenerator: store.TxSequence(),
+		IdentifierKey: func(id interface{}) (*key.Key, error) {
+			return key.NewBase("txs", id.(string)), nil
+		},
+	})
+
+	stub.MockTransactionStart("tx3")
+	d1, d2 := &Doc{Name: "a"}, &Doc{Name: "b"}
+	a.NoError(st.PutComposite(txs, d1))
+	a.NoError(st.PutComposite(txs, d2))
+	a.Equal("tx3.1", d1.ID)
+	a.Equal("tx3.2", d2.ID)
+	stub.MockTransactionEnd("tx3")
+	a.Equal("3", string(stub.State["#seq:seq"]))
+
+	_, err = store.Prepare(store.Composite{
+		Name:                "bad",
+		Creator:             func() interface{} { return &Doc{} },
+		IdentifierGetter:    func(v interface{}) interface{} { return v.(*Doc).ID },
+		IdentifierGenerator: store.TxSequence(),
+	})
+	a.Error(err)
+}
+
+func TestCompositePageAndRecord(t *testing.T) {
+	a := assert.New(t)
+
+	stub := shim.NewMockStub("test", nil)
+	st := store.New(stub)
+
+	stub.MockTransactionStart("put")
+	vals := []interface{}{}
+	for id := uint64(1); id <= 5; id++ {
+		v := &Compo{
+			Name:  "compo " + strconv.FormatUint(id, 10),
+			Thing: &Thing{ID: id, Name: "thing"},
+			Items: map[string]*Item{"a": {Name: "A", Quantity: float64(id)}},
+			Foos:  map[string]*Foo{},
+		}
+		a.NoError(st.PutComposite(cc, v))
+		vals = append(vals, v)
+	}
+	stub.MockTransactionEnd("put")
+
+	recs := []*store.Record{}
+	bookmark, pages := "", 0
+	for {
+		vs, next, err := st.GetCompositePage(cc, bookmark, 2)
+		a.NoError(err)
+		pages++
+		for _, v := range vs {
+			rec, err := cc.Record(v)
+			a.NoError(err)
+			recs = append(recs, rec)
+		}
+		if next == "" {
+			break
+		}
+		bookmark = next
+	}
+	a.Equal(3, pages)
+	a.Len(recs, 5)
+	a.Equal("compo:1", recs[0].Key)
+	a.Contains(recs[0].Collections, "item")
+
+	stub2 := shim.NewMockStub("test2", nil)
+	st2 := store.New(stub2)
+	stub2.MockTransactionStart("import")
+	for _, rec := range recs {
+		bs, err := json.Marshal(rec)
+		a.NoError(err)
+		rec2 := &store.Record{}
+		a.NoError(json.Unmarshal(bs, rec2))
+		v, err := cc.FromRecord(rec2)
+		a.NoError(err)
+		a.NoError(st2.PutComposite(cc, v))
+	}
+	stub2.MockTransactionEnd("import")
+	a.Equal(stub.State, stub2.State)
+
+	_, _, err := st.GetCompositePage(cc, "other:1", 2)
+	a.Error(err)
+	_, _, err = st.GetCompositePage(cc, "", store.MaxPageSize+1)
+	a.Error(err)
+}
+
+func TestCheckIntegrity(t *testing.T) {
+	a := assert.New(t)
+
+	stub := shim.NewMockStub("test", nil)
+	st := store.New(stub)
+
+	stub.MockTransactionStart("put")
+	for id := uint64(1); id <= 3; id++ {
+		a.NoError(st.PutComposite(cc, &Compo{Name: "compo", Thing: &Thing{ID: id}}))
+	}
+	a.NoError(stub.DelState("compo:1#wit"))                // orphan members
+	a.NoError(stub.PutState("compo:2#other", []byte("{"))) // invalid value
+	a.NoError(stub.PutState("compo:3#bogus", []byte("1"))) // unknown member
+	a.NoError(stub.PutState("compo:4#wit", []byte("1")))   // witness without members
+	stub.MockTransactionEnd("put")
+
+	kinds := func(r *store.IntegrityReport) []string {
+		ks := []string{}
+		for _, p := range r.Problems {
+			ks = append(ks, p.Kind)
+		}
+		return ks
+	}
+
+	stub.MockTransactionStart("check")
+	r, err := st.CheckIntegrity([]*store.Schema{cc}, "", 2, store.RepairNone)
+	a.NoError(err)
+	a.Equal(2, r.Scanned)
+	a.Equal("compo|compo:3", r.Continuation)
+	a.Equal([]string{store.ProblemMissingWitness, store.ProblemInvalidValue}, kinds(r))
+	r, err = st.CheckIntegrity([]*store.Schema{cc}, r.Continuation, 2, store.RepairNone)
+	a.NoError(err)
+	a.Equal(2, r.Scanned)
+	a.Empty(r.Continuation)
+	a.Equal([]string{store.ProblemUnknownMember, store.ProblemEmptyWitness}, kinds(r))
+	stub.MockTransactionEnd("check")
+
+	stub.MockTransactionStart("repair")
+	r, err = st.CheckIntegrity([]*store.Schema{cc}, "", 10, store.RepairRecreate)
+	a.NoError(err)
+	a.True(r.Problems[0].Repaired)
+	a.False(r.Problems[len(r.Problems)-1].Repaired)
+	stub.MockTransactionEnd("repair")
+	a.NotNil(stub.State["compo:1#wit"])
+
+	stub.MockTransactionStart("delete")
+	r, err = st.CheckIntegrity([]*store.Schema{cc}, "", 10, store.RepairDelete)
+	a.NoError(err)
+	a.Equal([]string{store.ProblemInvalidValue, store.ProblemUnknownMember, store.ProblemEmptyWitness}, kinds(r))
+	stub.MockTransactionEnd("delete")
+	a.Nil(stub.State["compo:4#wit"])
+
+	_, err = st.CheckIntegrity([]*store.Schema{cc}, "other|x", 10, store.RepairNone)
+	a.Error(err)
+	_, err = st.CheckIntegrity([]*store.Schema{cc}, "", store.MaxIntegrityLimit+1, store.RepairNone)
+	a.Error(err)
+}
+
+type Owned struct {
+	ID    uint64           `json:"id,omitempty"`
+	Owner string           `json:"owner,omitempty"`
+	Items map[string]*Item `json:"items,omitempty"`
+}
+
+func TestEndorsementPolicy(t *testing.T) {
+	a := assert.New(t)
+
+	occ := store.MustPrepare(store.Composite{
+		Name:              "owned",
+		Creator:           func() interface{} { return &Owned{} },
+		KeyBaseName:       "owned",
+		IdentifierField:   "ID",
+		IdentifierKey:     storeutil.Uint64Key("owned"),
+		KeyIdentifier:     storeutil.Uint64Identifier(0),
+		KeepRoot:          true,
+		EndorsementPolicy: store.OrgsPolicy(statebased.RoleTypePeer, store.FieldGetter("Owner")),
+		Collections: []store.Collection{
+			{Tag: "item", Field: "Items"},
+		},
+	})
+
+	stub := shim.NewMockStub("test", nil)
+	st := store.New(stub)
+
+	stub.MockTransactionStart("put")
+	a.NoError(st.PutComposite(occ, &Owned{ID: 1, Owner: "Org1MSP", Items: map[string]*Item{"a": {Name: "A"}}}))
+	a.NoError(st.PutComposite(occ, &Owned{ID: 2}))
+	stub.MockTransactionEnd("put")
+
+	policies := stub.EndorsementPolicies[""]
+	for _, k := range []string{"owned:1#wit", "owned:1", "owned:1#item:a"} {
+		a.NotNil(policies[k], k)
+	}
+	a.Nil(policies["owned:2#wit"])
+
+	policy, err := st.GetCompositePolicy(occ, uint64(1))
+	a.NoError(err)
+	orgs, err := store.PolicyOrgs(policy)
+	a.NoError(err)
+	a.Equal([]string{"Org1MSP"}, orgs)
+
+	stub.MockTransactionStart("patch")
+	a.NoError(st.PutCompositeCollection(occ.Collection("item"), uint64(1), map[string]*Item{"b": {Name: "B"}}))
+	a.NoError(st.PutCompositeCollection(occ.Collection("item"), uint64(2), map[string]*Item{"b": {Name: "B"}}))
+	stub.MockTransactionEnd("patch")
+	a.Equal(policy, policies["owned:1#item:b"])
+	a.Nil(policies["owned:2#item:b"])
+
+	policy, err = st.GetCompositePolicy(occ, uint64(2))
+	a.NoError(err)
+	a.Nil(policy)
+}
@@ -0,0 +1,93 @@
+package store
+
+import (
+	"github.com/pkg/errors"
+)
+
+// TypedSchema es un Schema cuyos valores son de tipo *T
+type TypedSchema[T any] struct {
+	*Schema
+}
+
+func MustPrepareTyped[T any](com Composite) *TypedSchema[T] {
+	ts, err := PrepareTyped[T](com)
+	if err != nil {
+		panic(err)
+	}
+	return ts
+}
+
+func PrepareTyped[T any](com Composite) (*TypedSchema[T], error) {
+	s, err := Prepare(com)
+	if err != nil {
+		return nil, err
+	}
+	return Typed[T](s)
+}
+
+func Typed[T any](s *Schema) (*TypedSchema[T], error) {
+	v, err := s.Create()
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := v.(*T); !ok {
+		return nil, errors.Errorf("composite %q creates values of type %T instead of %T", s.Name(), v, (*T)(nil))
+	}
+	return &TypedSchema[T]{Schema: s}, nil
+}
+
+func (ts *TypedSchema[T]) Get(st Store, id interface{}) (*T, error) {
+	v, err := st.GetComposite(ts.Schema, id)
+	if err != nil || v == nil {
+		return nil, err
+	}
+	return ts.cast(v)
+}
+
+func (ts *TypedSchema[T]) Put(st Store, val *T) error {
+	return st.PutComposite(ts.Schema, val)
+}
+
+func (ts *TypedSchema[T]) Has(st Store, id interface{}) (bool, error) {
+	return st.HasComposite(ts.Schema, id)
+}
+
+func (ts *TypedSchema[T]) Del(st Store, id interface{}) error {
+	return st.DelComposite(ts.Schema, id)
+}
+
+func (ts *TypedSchema[T]) All(st Store) ([]*T, error) {
+	vs, err := st.GetCompositeAll(ts.Schema)
+	if err != nil {
+		return nil, err
+	}
+	return ts.castAll(vs)
+}
+
+func (ts *TypedSchema[T]) Range(st Store, r *Range) ([]*T, error) {
+	vs, err := st.GetCompositeRange(ts.Schema, r)
+	if err != nil {
+		return nil, err
+	}
+	return ts.castAll(vs)
+}
+
+func (ts *TypedSchema[T]) cast(v interface{}) (*T, error) {
+	t, ok := v.(*T)
+	if !ok {
+		return nil, errors.Errorf("composite %q value has type %T instead of %T", ts.Name(), v, (*T)(nil))
+	}
+	return t, nil
+}
+
+func (ts *TypedSchema[T]) castAll(vs []interface{}) ([]*T, error) {
+	res := make([]*T, 0, len(vs))
+	for _, v := range vs {
+		t, err := ts.cast(v)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, t)
+	}
+	return res, nil
+}
@@ -0,0 +1,308 @@
+package store
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+// Rule valida un valor y devuelve las violaciones encontradas
+type Rule func(val interface{}) []Violation
+
+type Violation struct {
+	Field   string `json:"field,omitempty"`
+	Rule    string `json:"rule,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+func (v Violation) String() string {
+	if v.Field == "" {
+		return v.Message
+	}
+	return v.Field + ": " + v.Message
+}
+
+// ValidationError es el error de un valor que no cumple las reglas de su schema
+type ValidationError struct {
+	Schema     string      `json:"schema,omitempty"`
+	Violations []Violation `json:"violations,omitempty"`
+}
+
+func (e *ValidationError) Error() string {
+	ss := []string(nil)
+	for _, v := range e.Violations {
+		ss = append(ss, v.String())
+	}
+	return fmt.Sprintf("invalid composite %q: %s", e.Schema, strings.Join(ss, "; "))
+}
+
+func Required(field string) Rule {
+	return fieldRule(field, "required", func(v reflect.Value) string {
+		if !v.IsValid() || v.IsZero() {
+			return "is required"
+		}
+		return ""
+	})
+}
+
+// Length valida la longitud de un string, slice o map (max negativo es sin límite)
+func Length(field string, min, max int) Rule {
+	return fieldRule(field, "length", func(v reflect.Value) string {
+		if !v.IsValid() {
+			return ""
+		}
+		var l int
+		switch v.Kind() {
+		case reflect.String:
+			l = utf8.RuneCountInString(v.String())
+		case reflect.Slice, reflect.Map, reflect.Array:
+			l = v.Len()
+		default:
+			return fmt.Sprintf("has no length (%s)", v.Type())
+		}
+		if l < min {
+			return fmt.Sprintf("length %d is less than %d", l, min)
+		}
+		if max >= 0 && l > max {
+			return fmt.Sprintf("length %d is greater than %d", l, max)
+		}
+		return ""
+	})
+}
+
+func Pattern(field string, expr string) Rule {
+	re := regexp.MustCompile(expr)
+	return fieldRule(field, "pattern", func(v reflect.Value) string {
+		if !v.IsValid() || v.IsZero() {
+			return ""
+		}
+		if v.Kind() != reflect.String {
+			return fmt.Sprintf("is not a string (%s)", v.Type())
+		}
+		if !re.MatchString(v.String()) {
+			return fmt.Sprintf("value %q does not match pattern %q", v.String(), expr)
+		}
+		return ""
+	})
+}
+
+// Between valida que un campo numérico esté en el intervalo [min,max]
+func Between(field string, min, max float64) Rule {
+	return fieldRule(field, "range", func(v reflect.Value) string {
+		if !v.IsValid() {
+			return ""
+		}
+		var n float64
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			n = float64(v.Int())
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			n = float64(v.Uint())
+		case reflect.Float32, reflect.Float64:
+			n = v.Float()
+		default:
+			return fmt.Sprintf("is not a number (%s)", v.Type())
+		}
+		if n < min || n > max {
+			return fmt.Sprintf("value %v is out of range [%v,%v]", n, min, max)
+		}
+		return ""
+	})
+}
+
+// Custom valida el campo (o el valor completo si field es vacío) usando f
+func Custom(field string, f func(v interface{}) error) Rule {
+	return fieldRule(field, "custom", func(v reflect.Value) string {
+		var i interface{}
+		if v.IsValid() {
+			i = v.Interface()
+		}
+		if err := f(i); err != nil {
+			return err.Error()
+		}
+		return ""
+	})
+}
+
+const unknownFieldRule = "unknown-field"
+
+func fieldRule(field, rule string, check func(reflect.Value) string) Rule {
+	return func(val interface{}) []Violation {
+		v, name, err := fieldValue(val, field)
+		if err != nil {
+			return []Violation{{Field: field, Rule: unknownFieldRule, Message: err.Error()}}
+		}
+		if msg := check(v); msg != "" {
+			return []Violation{{Field: name, Rule: rule, Message: msg}}
+		}
+		return nil
+	}
+}
+
+func fieldValue(val interface{}, field string) (reflect.Value, string, error) {
+	v := reflect.ValueOf(val)
+	if field == "" {
+		for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+			if v.IsNil() {
+				return reflect.Value{}, "", nil
+			}
+			v = v.Elem()
+		}
+		return v, "", nil
+	}
+	t := reflect.TypeOf(val)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return reflect.Value{}, field, errors.Errorf("field %q not found in %v", field, t)
+	}
+	sf, ok := t.FieldByName(field)
+	if !ok {
+		return reflect.Value{}, field, errors.Errorf("field %q not found in %s", field, t)
+	}
+	name := field
+	if tag := strings.Split(sf.Tag.Get("json"), ",")[0]; tag != "" && tag != "-" {
+		name = tag
+	}
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return reflect.Value{}, name, nil
+		}
+		v = v.Elem()
+	}
+	f := v.FieldByIndex(sf.Index)
+	for f.Kind() == reflect.Ptr || f.Kind() == reflect.Interface {
+		if f.IsNil() {
+			return reflect.Value{}, name, nil
+		}
+		f = f.Elem()
+	}
+	return f, name, nil
+}
+
+func checkRules(rules []Rule, sample interface{}) error {
+	if sample == nil {
+		return nil
+	}
+	for _, rule := range rules {
+		for _, v := range sampleViolations(rule, sample) {
+			if v.Rule == unknownFieldRule {
+				return errors.New(v.Message)
+			}
+		}
+	}
+	return nil
+}
+
+func sampleViolations(rule Rule, sample interface{}) (vs []Violation) {
+	defer func() {
+		if recover() != nil {
+			vs = nil // las reglas custom pueden no admitir valores vacíos
+		}
+	}()
+	return rule(sample)
+}
+
+func validate(rules []Rule, prefix string, val interface{}) []Violation {
+	vs := []Violation(nil)
+	for _, rule := range rules {
+		for _, v := range rule(val) {
+			v.Field = join(prefix, v.Field)
+			vs = append(vs, v)
+		}
+	}
+	return vs
+}
+
+func join(prefix, field string) string {
+	switch {
+	case prefix == "":
+		return field
+	case field == "":
+		return prefix
+	default:
+		return prefix + "." + field
+	}
+}
+
+func validationError(name string, vs []Violation) error {
+	if len(vs) == 0 {
+		return nil
+	}
+	return &ValidationError{Schema: name, Violations: vs}
+}
+
+func (cc *Schema) Validate(val interface{}) (err error) {
+	defer func() {
+		p := recover()
+		if p != nil {
+			err = errors.Errorf("validating composite %q value: %v", cc.name, p)
+		}
+	}()
+	vs := validate(cc.composite.Validations, "", val)
+	for _, singleton := range cc.singletons {
+		vs = append(vs, singleton.violations(singleton.Getter(val))...)
+	}
+	for _, collection := range cc.collections {
+		vs = append(vs, collection.violations(collection.Getter(val))...)
+	}
+	return validationError(cc.name, vs)
+}
+
+func (s *Singleton) Validate(val interface{}) (err error) {
+	defer func() {
+		p := recover()
+		if p != nil {
+			err = errors.Errorf("validating composite %q singleton %q value: %v", s.schema.name, s.Tag, p)
+		}
+	}()
+	return validationError(s.schema.name, s.violations(val))
+}
+
+func (s *Singleton) violations(val interface{}) []Violation {
+	if len(s.Validations) == 0 || isNil(val) {
+		return nil
+	}
+	return validate(s.Validations, s.Tag, val)
+}
+
+func (c *Collection) Validate(col interface{}) (err error) {
+	defer func() {
+		p := recover()
+		if p != nil {
+			err = errors.Errorf("validating composite %q collection %q items: %v", c.schema.name, c.Tag, p)
+		}
+	}()
+	return validationError(c.schema.name, c.violations(col))
+}
+
+func (c *Collection) violations(col interface{}) []Violation {
+	if len(c.Validations) == 0 || isNil(col) {
+		return nil
+	}
+	vs := []Violation(nil)
+	for _, item := range c.Enumerator(col) {
+		if isNil(item.Value) {
+			continue // nil items are deletions
+		}
+		vs = append(vs, validate(c.Validations, c.Tag+"["+item.Identifier+"]", item.Value)...)
+	}
+	return vs
+}
+
+func isNil(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice:
+		return rv.IsNil()
+	}
+	return false
+}
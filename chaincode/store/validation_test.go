@@ -0,0 +1,125 @@
+package store_test
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lalloni/fabrikit/chaincode/store"
+	"github.com/lalloni/fabrikit/chaincode/store/key"
+)
+
+var vcc = store.MustPrepare(store.Composite{
+	Name:        "vcompo",
+	Creator:     func() interface{} { return &Compo{} },
+	KeyBaseName: "vcompo",
+	IdentifierGetter: func(v interface{}) interface{} {
+		return v.(*Compo).Thing.ID
+	},
+	IdentifierKey: func(id interface{}) (*key.Key, error) {
+		return key.NewBase("vcompo", strconv.FormatUint(id.(uint64), 10)), nil
+	},
+	KeyIdentifier: func(k *key.Key) (interface{}, error) {
+		return strconv.ParseUint(k.Base[0].Value, 10, 64)
+	},
+	Validations: []store.Rule{
+		store.Length("Name", 0, 10),
+	},
+	Singletons: []store.Singleton{
+		{Tag: "thing", Field: "Thing", Validations: []store.Rule{
+			store.Required("Name"),
+			store.Pattern("Name", "^[A-Z]+$"),
+			store.Between("Age", 18, 99),
+		}},
+	},
+	Collections: []store.Collection{
+		{Tag: "item", Field: "Items", Validations: []store.Rule{
+			store.Custom("Quantity", func(v interface{}) error {
+				if v.(float64) <= 0 {
+					return errors.New("must be positive")
+				}
+				return nil
+			}),
+		}},
+	},
+})
+
+func TestValidation(t *testing.T) {
+	a := assert.New(t)
+
+	stub := shim.NewMockStub("test", nil)
+	st := store.New(stub)
+
+	c1 := &Compo{
+		Thing: &Thing{ID: 1, Name: "PP", Age: 20},
+		Items: map[string]*Item{"a": {Name: "Pedro", Quantity: 10.0}},
+		Name:  "short",
+	}
+	stub.MockTransactionStart("x")
+	err := st.PutComposite(vcc, c1)
+	stub.MockTransactionEnd("x")
+	a.NoError(err)
+
+	c2 := &Compo{
+		Thing: &Thing{ID: 2, Name: "pp", Age: 10},
+		Items: map[string]*Item{"a": {Name: "Pedro", Quantity: -1}},
+		Name:  "a very long name",
+	}
+	stub.MockTransactionStart("x")
+	err = st.PutComposite(vcc, c2)
+	stub.MockTransactionEnd("x")
+	a.Error(err)
+	verr, ok := err.(*store.ValidationError)
+	a.True(ok)
+	a.ElementsMatch([]string{"name", "thing.name", "thing.age", "item[a].quantity"}, fields(verr))
+	has, err := st.HasComposite(vcc, uint64(2))
+	a.NoError(err)
+	a.False(has)
+
+	stub.MockTransactionStart("x")
+	err = st.PutCompositeSingleton(vcc.Singleton("thing"), uint64(1), &Thing{ID: 1})
+	stub.MockTransactionEnd("x")
+	a.Error(err)
+	a.IsType(&store.ValidationError{}, err)
+
+	stub.MockTransactionStart("x")
+	err = st.PutCompositeCollection(vcc.Collection("item"), uint64(1), map[string]*Item{"a": nil, "b": {Quantity: 0}})
+	stub.MockTransactionEnd("x")
+	a.Error(err)
+	a.EqualValues([]string{"item[b].quantity"}, fields(err.(*store.ValidationError)))
+}
+
+func fields(verr *store.ValidationError) []string {
+	ss := []string{}
+	for _, v := range verr.Violations {
+		ss = append(ss, v.Field)
+	}
+	return ss
+}
+
+func TestValidationUnknownField(t *testing.T) {
+	a := assert.New(t)
+
+	com := func(rules ...store.Rule) store.Composite {
+		return store.Composite{
+			Name:             "bad",
+			Creator:          func() interface{} { return &Compo{} },
+			KeyBaseName:      "bad",
+			IdentifierGetter: func(v interface{}) interface{} { return v.(*Compo).Thing.ID },
+			Validations:      rules,
+			Singletons: []store.Singleton{
+				{Tag: "thing", Field: "Thing", Validations: []store.Rule{store.Required("Nmae")}},
+			},
+		}
+	}
+	_, err := store.Prepare(com(store.Length("Missing", 0, 1)))
+	a.EqualError(err, `composite "bad" validation rule: field "Missing" not found in store_test.Compo`)
+
+	_, err = store.Prepare(com())
+	a.EqualError(err, `composite "bad" singleton "thing" validation rule: field "Nmae" not found in store_test.Thing`)
+
+	a.Panics(func() { store.MustPrepare(com()) })
+}
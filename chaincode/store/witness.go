@@ -0,0 +1,157 @@
+package store
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/lalloni/fabrikit/chaincode/store/key"
+)
+
+// Witness es el valor del testigo de un composite con ciclo de vida
+type Witness struct {
+	Deleted *AuditEntry `json:"deleted,omitempty"`
+	Expires *time.Time  `json:"expires,omitempty"`
+}
+
+func (w *Witness) empty() bool {
+	return w.Deleted == nil && w.Expires == nil
+}
+
+func witnessValue(w *Witness) interface{} {
+	if w == nil || w.empty() {
+		return 1
+	}
+	return w
+}
+
+// tracksWitness indica si el testigo determina la visibilidad del composite
+func (cc *Schema) tracksWitness() bool {
+	return cc.composite.SoftDelete || cc.expires()
+}
+
+func (ss *simplestore) getWitness(wk *key.Key) (*Witness, bool, error) {
+	bs, err := ss.stub.GetState(wk.StringUsing(ss.sep))
+	if err != nil {
+		return nil, false, errors.Wrap(err, "getting witness from state")
+	}
+	if bs == nil {
+		return nil, false, nil
+	}
+	w, err := ss.parseWitness(bs)
+	if err != nil {
+		return nil, false, err
+	}
+	return w, true, nil
+}
+
+func (ss *simplestore) parseWitness(bs []byte) (*Witness, error) {
+	w := &Witness{}
+	if err := ss.internalParseValue(bs, w); err != nil {
+		var v interface{}
+		if err := ss.internalParseValue(bs, &v); err != nil {
+			return nil, errors.Wrap(err, "parsing witness")
+		}
+		return &Witness{}, nil // testigo simple
+	}
+	return w, nil
+}
+
+func (ss *simplestore) visible(w *Witness) (bool, error) {
+	expired, err := ss.expired(w)
+	if err != nil {
+		return false, err
+	}
+	return !expired && (w.Deleted == nil || ss.deleted), nil
+}
+
+func (ss *simplestore) softDeleteComposite(s *Schema, id interface{}, valkey *key.Key) (bool, error) {
+	wk := s.KeyWitness(valkey)
+	w, found, err := ss.getWitness(wk)
+	if err != nil {
+		return false, errors.Wrapf(err, "getting composite %q witness with key %q", s.Name(), wk)
+	}
+	if !found || w.Deleted != nil {
+		return false, nil
+	}
+	if err := ss.tombstone(s, id, wk, w); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (ss *simplestore) tombstone(s *Schema, id interface{}, wk *key.Key, w *Witness) error {
+	entry, err := ss.auditEntry()
+	if err != nil {
+		return errors.Wrapf(err, "getting composite %q deletion entry", s.Name())
+	}
+	w.Deleted = entry
+	if err := ss.internalPutValue(wk, witnessValue(w)); err != nil {
+		return errors.Wrapf(err, "putting composite %q witness with key %q tombstone", s.Name(), wk)
+	}
+	ss.recordChange(s, id, OperationDelete, "", nil)
+	return nil
+}
+
+func (ss *simplestore) RestoreComposite(s *Schema, id interface{}) (bool, error) {
+	valkey, err := s.IdentifierKey(id)
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+	wk := s.KeyWitness(valkey)
+	w, found, err := ss.getWitness(wk)
+	if err != nil {
+		return false, errors.Wrapf(err, "getting composite %q witness with key %q", s.Name(), wk)
+	}
+	if !found || w.Deleted == nil {
+		return false, nil
+	}
+	w.Deleted = nil
+	if err := ss.internalPutValue(wk, witnessValue(w)); err != nil {
+		return false, errors.Wrapf(err, "putting composite %q witness with key %q", s.Name(), wk)
+	}
+	if err := ss.touchAudit(s, valkey); err != nil {
+		return false, errors.WithStack(err)
+	}
+	ss.recordChange(s, id, OperationRestore, "", nil)
+	return true, nil
+}
+
+func (ss *simplestore) PurgeComposite(s *Schema, id interface{}) (bool, error) {
+	valkey, err := s.IdentifierKey(id)
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+	wk := s.KeyWitness(valkey)
+	_, found, err := ss.getWitness(wk)
+	if err != nil {
+		return false, errors.Wrapf(err, "getting composite %q witness with key %q", s.Name(), wk)
+	}
+	if !found {
+		return false, nil
+	}
+	if err := ss.internalPurgeComposite(s, valkey); err != nil {
+		return false, err
+	}
+	ss.recordChange(s, id, OperationPurge, "", nil)
+	return true, nil
+}
+
+func (ss *simplestore) internalPurgeComposite(s *Schema, valkey *key.Key) error {
+	states, err := ss.stub.GetStateByRange(valkey.RangeUsing(ss.sep))
+	if err != nil {
+		return errors.Wrapf(err, "getting composite %q states with key %q for deletion", s.Name(), valkey)
+	}
+	defer states.Close()
+	for states.HasNext() {
+		state, err := states.Next()
+		if err != nil {
+			return errors.Wrapf(err, "getting composite %q with key %q next state for deletion", s.Name(), valkey)
+		}
+		err = ss.stub.DelState(state.GetKey())
+		if err != nil {
+			return errors.Wrapf(err, "deleting composite %q with key %q state %q", s.Name(), valkey, state.GetKey())
+		}
+	}
+	return nil
+}